@@ -0,0 +1,60 @@
+// Package agentfs implements a SQLite-backed virtual filesystem geared
+// towards coding agents: every file and directory is a row in a single
+// SQLite database, so a whole project tree can be snapshotted, diffed, or
+// handed to a sandboxed agent without touching the host filesystem.
+package agentfs
+
+import "context"
+
+// AgentFSOptions configures a call to Open.
+type AgentFSOptions struct {
+	// Path is the SQLite data source. Use ":memory:" for an ephemeral,
+	// process-local filesystem, or ":fake:" to select a MemBackend instead
+	// of SQLite entirely. Ignored when Backend is set.
+	Path string
+
+	// Backend overrides the storage engine. Most callers leave this nil and
+	// use Path; it exists for tests and embedders that want a MemBackend (or
+	// their own Backend implementation) instead of SQLite.
+	Backend Backend
+
+	// Cache configures the optional path/stat cache. The zero value
+	// leaves caching disabled.
+	Cache CacheOptions
+}
+
+// AgentFS is a handle to an open virtual filesystem.
+type AgentFS struct {
+	FS *FS
+
+	store Backend
+}
+
+// Open creates or opens the filesystem described by opts.
+func Open(ctx context.Context, opts AgentFSOptions) (*AgentFS, error) {
+	store := opts.Backend
+	if store == nil {
+		if opts.Path == fakeBackendPath {
+			store = NewMemBackend()
+		} else {
+			var err error
+			store, err = openSQLiteStore(ctx, opts.Path)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &AgentFS{
+		FS:    newFS(store, opts.Cache),
+		store: store,
+	}, nil
+}
+
+// Close flushes any pending writeback data and releases the underlying
+// database handle.
+func (a *AgentFS) Close() error {
+	if err := a.FS.Sync(context.Background()); err != nil {
+		return err
+	}
+	return a.store.Close()
+}
@@ -0,0 +1,52 @@
+package agentfs
+
+import (
+	"context"
+	"io/fs"
+)
+
+// fakeBackendPath is the AgentFSOptions.Path sentinel that selects a
+// MemBackend instead of opening a SQLite database.
+const fakeBackendPath = ":fake:"
+
+// Backend abstracts the inode/dirent storage engine underneath FS. The
+// default is sqliteStore; MemBackend is a pure-Go in-memory implementation
+// with identical semantics, for hermetic tests that don't want SQLite's
+// file and setup overhead. FS, the cache layer, and path resolution are
+// unaware of which Backend they're talking to.
+type Backend interface {
+	// Resolve walks path from the root and returns its inode, or
+	// ErrNotExist.
+	Resolve(ctx context.Context, path string) (*inode, error)
+
+	// Children returns the direct children of the directory inode dirID.
+	Children(ctx context.Context, dirID int64) ([]*inode, error)
+
+	// CreateFile creates or overwrites the file at path with data.
+	CreateFile(ctx context.Context, path string, data []byte, mode fs.FileMode) (*inode, error)
+
+	// Mkdir creates the directory at path, or ErrExist if it is already
+	// present.
+	Mkdir(ctx context.Context, path string, mode fs.FileMode) (*inode, error)
+
+	// Unlink removes the file at path.
+	Unlink(ctx context.Context, path string) error
+
+	// Rmdir removes the directory at path, or ErrNotEmpty if it has
+	// children.
+	Rmdir(ctx context.Context, path string) error
+
+	// Rename moves oldPath to newPath, overwriting newPath if present,
+	// following POSIX rename(2) semantics for type mismatches: ErrIsDir if
+	// newPath is a directory and oldPath is not, ErrNotDir if oldPath is a
+	// directory and newPath is not, and ErrNotEmpty if both are directories
+	// but newPath is non-empty.
+	Rename(ctx context.Context, oldPath, newPath string) error
+
+	// ReadRange returns up to length bytes starting at offset from inode
+	// id's data, without requiring the full contents to be loaded first.
+	ReadRange(ctx context.Context, id int64, offset, length int64) ([]byte, error)
+
+	// Close releases any resources held by the backend.
+	Close() error
+}
@@ -0,0 +1,201 @@
+package agentfs
+
+import (
+	"context"
+	"testing"
+)
+
+// runBackendConformance exercises the Backend contract directly (below the
+// cache and path-resolution layers in FS), so sqliteStore and MemBackend can
+// share a single suite of invalidation and error-semantics cases.
+func runBackendConformance(t *testing.T, newBackend func(t *testing.T) Backend) {
+	t.Helper()
+	ctx := context.Background()
+
+	b := newBackend(t)
+	if _, err := b.CreateFile(ctx, "/a.txt", []byte("hello"), 0o644); err != nil {
+		t.Fatalf("CreateFile failed: %v", err)
+	}
+	ino, err := b.Resolve(ctx, "/a.txt")
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if string(ino.Data) != "hello" {
+		t.Errorf("Resolve data = %q, want %q", ino.Data, "hello")
+	}
+
+	b = newBackend(t)
+	if _, err := b.Mkdir(ctx, "/dir", 0o755); err != nil {
+		t.Fatalf("Mkdir failed: %v", err)
+	}
+	if _, err := b.Mkdir(ctx, "/dir", 0o755); !IsExist(err) {
+		t.Errorf("Mkdir of existing dir = %v, want ErrExist", err)
+	}
+	if _, err := b.CreateFile(ctx, "/dir/f.txt", []byte("x"), 0o644); err != nil {
+		t.Fatalf("CreateFile under dir failed: %v", err)
+	}
+	dirIno, err := b.Resolve(ctx, "/dir")
+	if err != nil {
+		t.Fatalf("Resolve(/dir) failed: %v", err)
+	}
+	children, err := b.Children(ctx, dirIno.ID)
+	if err != nil {
+		t.Fatalf("Children failed: %v", err)
+	}
+	if len(children) != 1 || children[0].Name != "f.txt" {
+		t.Errorf("Children(/dir) = %v, want [f.txt]", children)
+	}
+
+	b = newBackend(t)
+	b.CreateFile(ctx, "/unlink.txt", []byte("bye"), 0o644)
+	if err := b.Unlink(ctx, "/unlink.txt"); err != nil {
+		t.Fatalf("Unlink failed: %v", err)
+	}
+	if _, err := b.Resolve(ctx, "/unlink.txt"); !IsNotExist(err) {
+		t.Errorf("Resolve after Unlink = %v, want ErrNotExist", err)
+	}
+
+	b = newBackend(t)
+	b.Mkdir(ctx, "/empty", 0o755)
+	b.Mkdir(ctx, "/full", 0o755)
+	b.CreateFile(ctx, "/full/child.txt", []byte("x"), 0o644)
+	if err := b.Rmdir(ctx, "/empty"); err != nil {
+		t.Errorf("Rmdir of empty dir failed: %v", err)
+	}
+	if err := b.Rmdir(ctx, "/full"); err != ErrNotEmpty {
+		t.Errorf("Rmdir of non-empty dir = %v, want ErrNotEmpty", err)
+	}
+
+	b = newBackend(t)
+	b.CreateFile(ctx, "/old.txt", []byte("moved"), 0o644)
+	if err := b.Rename(ctx, "/old.txt", "/new.txt"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if _, err := b.Resolve(ctx, "/old.txt"); !IsNotExist(err) {
+		t.Errorf("Resolve(/old.txt) after Rename = %v, want ErrNotExist", err)
+	}
+	renamed, err := b.Resolve(ctx, "/new.txt")
+	if err != nil {
+		t.Fatalf("Resolve(/new.txt) after Rename failed: %v", err)
+	}
+	if string(renamed.Data) != "moved" {
+		t.Errorf("Resolve(/new.txt) data = %q, want %q", renamed.Data, "moved")
+	}
+
+	b = newBackend(t)
+	b.CreateFile(ctx, "/range.txt", []byte("0123456789"), 0o644)
+	full, _ := b.Resolve(ctx, "/range.txt")
+	part, err := b.ReadRange(ctx, full.ID, 3, 4)
+	if err != nil {
+		t.Fatalf("ReadRange failed: %v", err)
+	}
+	if string(part) != "3456" {
+		t.Errorf("ReadRange(3, 4) = %q, want %q", part, "3456")
+	}
+
+	// Rename onto an existing destination must overwrite it (POSIX
+	// semantics), not fail with ErrExist.
+	b = newBackend(t)
+	b.CreateFile(ctx, "/src.txt", []byte("A"), 0o644)
+	b.CreateFile(ctx, "/dst.txt", []byte("B"), 0o644)
+	if err := b.Rename(ctx, "/src.txt", "/dst.txt"); err != nil {
+		t.Fatalf("Rename onto existing destination failed: %v", err)
+	}
+	if _, err := b.Resolve(ctx, "/src.txt"); !IsNotExist(err) {
+		t.Errorf("Resolve(/src.txt) after overwrite-rename = %v, want ErrNotExist", err)
+	}
+	overwritten, err := b.Resolve(ctx, "/dst.txt")
+	if err != nil {
+		t.Fatalf("Resolve(/dst.txt) after overwrite-rename failed: %v", err)
+	}
+	if string(overwritten.Data) != "A" {
+		t.Errorf("Resolve(/dst.txt) data = %q, want %q", overwritten.Data, "A")
+	}
+
+	// A caller must not be able to mutate backend state by reusing and
+	// changing its buffer after CreateFile returns.
+	b = newBackend(t)
+	buf := []byte("original")
+	if _, err := b.CreateFile(ctx, "/alias.txt", buf, 0o644); err != nil {
+		t.Fatalf("CreateFile failed: %v", err)
+	}
+	buf[0] = 'X'
+	aliased, err := b.Resolve(ctx, "/alias.txt")
+	if err != nil {
+		t.Fatalf("Resolve(/alias.txt) failed: %v", err)
+	}
+	if string(aliased.Data) != "original" {
+		t.Errorf("Resolve(/alias.txt) data = %q, want %q (mutating the caller's buffer after CreateFile must not affect stored data)", aliased.Data, "original")
+	}
+
+	// Rename of a file onto an existing *empty* directory destination is
+	// still rejected: the file/directory type mismatch alone means ErrIsDir.
+	b = newBackend(t)
+	b.CreateFile(ctx, "/srcfile.txt", []byte("A"), 0o644)
+	b.Mkdir(ctx, "/emptydst", 0o755)
+	if err := b.Rename(ctx, "/srcfile.txt", "/emptydst"); err != ErrIsDir {
+		t.Errorf("Rename(file, empty dir) = %v, want ErrIsDir", err)
+	}
+
+	// Rename of a file onto a non-empty directory destination must be
+	// rejected, not silently delete the directory and orphan its children.
+	b = newBackend(t)
+	b.Mkdir(ctx, "/destdir", 0o755)
+	b.CreateFile(ctx, "/destdir/child.txt", []byte("kept"), 0o644)
+	b.CreateFile(ctx, "/src2.txt", []byte("B"), 0o644)
+	if err := b.Rename(ctx, "/src2.txt", "/destdir"); err != ErrIsDir {
+		t.Errorf("Rename(file, non-empty dir) = %v, want ErrIsDir", err)
+	}
+	destIno, err := b.Resolve(ctx, "/destdir")
+	if err != nil {
+		t.Fatalf("Resolve(/destdir) after rejected rename failed: %v", err)
+	}
+	if !destIno.IsDir {
+		t.Error("/destdir was overwritten by a rejected Rename, want it to still be a directory")
+	}
+	if _, err := b.Resolve(ctx, "/destdir/child.txt"); err != nil {
+		t.Errorf("Resolve(/destdir/child.txt) after rejected Rename = %v, want nil (child must survive)", err)
+	}
+	if _, err := b.Resolve(ctx, "/src2.txt"); err != nil {
+		t.Errorf("Resolve(/src2.txt) after rejected Rename = %v, want nil (source must be untouched)", err)
+	}
+
+	// Rename of a directory onto an existing file destination must be
+	// rejected (type mismatch the other way around).
+	b = newBackend(t)
+	b.Mkdir(ctx, "/srcdir", 0o755)
+	b.CreateFile(ctx, "/dstfile.txt", []byte("x"), 0o644)
+	if err := b.Rename(ctx, "/srcdir", "/dstfile.txt"); err != ErrNotDir {
+		t.Errorf("Rename(dir, file) = %v, want ErrNotDir", err)
+	}
+
+	// Rename of a directory onto an existing non-empty directory
+	// destination must be rejected.
+	b = newBackend(t)
+	b.Mkdir(ctx, "/srcdir2", 0o755)
+	b.Mkdir(ctx, "/destdir2", 0o755)
+	b.CreateFile(ctx, "/destdir2/child.txt", []byte("kept"), 0o644)
+	if err := b.Rename(ctx, "/srcdir2", "/destdir2"); err != ErrNotEmpty {
+		t.Errorf("Rename(dir, non-empty dir) = %v, want ErrNotEmpty", err)
+	}
+	if _, err := b.Resolve(ctx, "/destdir2/child.txt"); err != nil {
+		t.Errorf("Resolve(/destdir2/child.txt) after rejected Rename = %v, want nil (child must survive)", err)
+	}
+}
+
+func TestSQLiteBackend_Conformance(t *testing.T) {
+	runBackendConformance(t, func(t *testing.T) Backend {
+		s, err := openSQLiteStore(context.Background(), ":memory:")
+		if err != nil {
+			t.Fatalf("openSQLiteStore failed: %v", err)
+		}
+		t.Cleanup(func() { s.Close() })
+		return s
+	})
+}
+
+func TestMemBackend_Conformance(t *testing.T) {
+	runBackendConformance(t, func(t *testing.T) Backend {
+		return NewMemBackend()
+	})
+}
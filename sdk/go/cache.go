@@ -0,0 +1,238 @@
+package agentfs
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CacheOptions configures the in-memory path/stat cache that sits in front
+// of the inode store. It is zero-value safe: the zero value disables
+// caching entirely.
+type CacheOptions struct {
+	// Enabled turns the cache on. When false, CacheStats returns nil and
+	// every lookup goes straight to the store.
+	Enabled bool
+
+	// MaxEntries bounds the number of cached path entries. Once exceeded,
+	// the least recently used entry is evicted. Zero means unbounded.
+	MaxEntries int
+
+	// Writeback enables deferred-write caching: WriteFile, Write, and
+	// Truncate land in an in-memory dirty buffer and return immediately,
+	// with a background flusher persisting them to the store after this
+	// delay. Zero (the default) disables writeback, so every write is
+	// synchronous. Requires Enabled.
+	Writeback time.Duration
+
+	// WritebackMaxDirty bounds the total bytes held in the dirty buffer
+	// across all inodes. A write that would exceed it returns
+	// ErrDirtyBufferFull immediately rather than growing the buffer
+	// further; the caller can retry once the flusher catches up. Zero
+	// means unbounded.
+	WritebackMaxDirty int
+
+	// ChunkSize sets the chunk size of the second-tier content cache:
+	// ReadAt breaks requests into chunks of this size, cached in an LRU
+	// keyed by (inode, chunk index). ReadFile is not served from this
+	// cache. The content cache is active whenever Enabled is true; a zero
+	// ChunkSize means 64 KiB.
+	ChunkSize int
+
+	// MaxBytes bounds the total size of cached chunk data. Eviction is
+	// byte-driven rather than entry-driven, since chunks near the end of
+	// a file can be shorter than ChunkSize. Zero means unbounded.
+	MaxBytes int
+}
+
+// defaultChunkSize is applied when caching is enabled but the caller left
+// ChunkSize unset.
+const defaultChunkSize = 64 * 1024
+
+// CacheStats reports cumulative cache activity. All counters are updated
+// atomically so CacheStats can be called from any goroutine while the
+// filesystem is in use.
+type CacheStats struct {
+	Hits    uint64
+	Misses  uint64
+	Entries uint64
+
+	// DirtyEntries and DirtyBytes describe the writeback buffer; both are
+	// zero when Writeback is disabled.
+	DirtyEntries uint64
+	DirtyBytes   uint64
+
+	// FlushCount and FlushLatency track background flush activity.
+	// FlushLatency is the cumulative time spent inside flush calls, so
+	// FlushLatency/FlushCount gives the average flush latency.
+	FlushCount   uint64
+	FlushLatency time.Duration
+
+	// ChunkHits, ChunkMisses, BytesServedFromCache, and ResidentBytes
+	// describe the second-tier content cache, which runs whenever caching
+	// is enabled.
+	ChunkHits            uint64
+	ChunkMisses          uint64
+	BytesServedFromCache uint64
+	ResidentBytes        uint64
+
+	// DirListingHits and DirListingMisses describe the directory-listing
+	// cache used by FS.Find.
+	DirListingHits   uint64
+	DirListingMisses uint64
+}
+
+// HitRate returns the fraction of lookups served from cache, as a
+// percentage in [0, 100]. It returns 0 when there has been no traffic.
+func (s *CacheStats) HitRate() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total) * 100
+}
+
+// cacheEntry is a cached path -> inode resolution.
+type cacheEntry struct {
+	ino  *inode
+	prev *cacheEntry
+	next *cacheEntry
+	path string
+}
+
+// pathCache is an LRU cache mapping cleaned paths to resolved inodes. It
+// guards its own state with a single mutex; callers that need to coordinate
+// cache invalidation with other filesystem state (e.g. the writeback
+// buffer) take this lock rather than introducing a second one.
+type pathCache struct {
+	mu         sync.RWMutex
+	opts       CacheOptions
+	entries    map[string]*cacheEntry
+	head, tail *cacheEntry // head = most recently used
+
+	hits, misses uint64
+
+	// dirty holds buffered writes awaiting flush, keyed by cleaned path. It
+	// is guarded by mu so invalidation and writeback never race: a Rename
+	// or Unlink that touches a dirty path sees a consistent view of it.
+	dirty      map[string]*dirtyRecord
+	dirtyBytes int64
+
+	flushCount   uint64
+	flushLatency time.Duration
+}
+
+func newPathCache(opts CacheOptions) *pathCache {
+	return &pathCache{
+		opts:    opts,
+		entries: make(map[string]*cacheEntry),
+		dirty:   make(map[string]*dirtyRecord),
+	}
+}
+
+func (c *pathCache) get(path string) (*inode, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[path]
+	if !ok {
+		atomic.AddUint64(&c.misses, 1)
+		return nil, false
+	}
+	atomic.AddUint64(&c.hits, 1)
+	c.moveToFront(e)
+	return e.ino, true
+}
+
+func (c *pathCache) put(path string, ino *inode) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[path]; ok {
+		e.ino = ino
+		c.moveToFront(e)
+		return
+	}
+	e := &cacheEntry{ino: ino, path: path}
+	c.entries[path] = e
+	c.pushFront(e)
+	if c.opts.MaxEntries > 0 && len(c.entries) > c.opts.MaxEntries {
+		c.evictOldest()
+	}
+}
+
+// invalidate drops path, and any cached path nested under it (for directory
+// invalidation on Rmdir/Rename), from the cache.
+func (c *pathCache) invalidate(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	prefix := path + "/"
+	for p, e := range c.entries {
+		if p == path || len(p) > len(prefix) && p[:len(prefix)] == prefix {
+			c.remove(e)
+			delete(c.entries, p)
+		}
+	}
+}
+
+func (c *pathCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*cacheEntry)
+	c.head, c.tail = nil, nil
+}
+
+func (c *pathCache) stats() *CacheStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return &CacheStats{
+		Hits:         atomic.LoadUint64(&c.hits),
+		Misses:       atomic.LoadUint64(&c.misses),
+		Entries:      uint64(len(c.entries)),
+		DirtyEntries: uint64(len(c.dirty)),
+		DirtyBytes:   uint64(c.dirtyBytes),
+		FlushCount:   atomic.LoadUint64(&c.flushCount),
+		FlushLatency: c.flushLatency,
+	}
+}
+
+// --- intrusive doubly-linked list, mu must be held ---
+
+func (c *pathCache) pushFront(e *cacheEntry) {
+	e.prev, e.next = nil, c.head
+	if c.head != nil {
+		c.head.prev = e
+	}
+	c.head = e
+	if c.tail == nil {
+		c.tail = e
+	}
+}
+
+func (c *pathCache) remove(e *cacheEntry) {
+	if e.prev != nil {
+		e.prev.next = e.next
+	} else {
+		c.head = e.next
+	}
+	if e.next != nil {
+		e.next.prev = e.prev
+	} else {
+		c.tail = e.prev
+	}
+	e.prev, e.next = nil, nil
+}
+
+func (c *pathCache) moveToFront(e *cacheEntry) {
+	if c.head == e {
+		return
+	}
+	c.remove(e)
+	c.pushFront(e)
+}
+
+func (c *pathCache) evictOldest() {
+	if c.tail == nil {
+		return
+	}
+	delete(c.entries, c.tail.path)
+	c.remove(c.tail)
+}
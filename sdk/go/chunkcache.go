@@ -0,0 +1,179 @@
+package agentfs
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// chunkKey identifies one fixed-size slice of a file's contents.
+type chunkKey struct {
+	inode int64
+	index int
+}
+
+type chunkEntry struct {
+	key        chunkKey
+	data       []byte
+	prev, next *chunkEntry
+}
+
+// chunkCache is the LRU content cache backing ReadAt. Eviction is
+// byte-driven (MaxBytes) since chunks vary in size at end-of-file.
+type chunkCache struct {
+	mu         sync.Mutex
+	opts       CacheOptions
+	entries    map[chunkKey]*chunkEntry
+	head, tail *chunkEntry
+	totalBytes int64
+
+	hits, misses, bytesServed uint64
+}
+
+func newChunkCache(opts CacheOptions) *chunkCache {
+	return &chunkCache{
+		opts:    opts,
+		entries: make(map[chunkKey]*chunkEntry),
+	}
+}
+
+func (c *chunkCache) chunkIndex(offset int64) int {
+	return int(offset / int64(c.opts.ChunkSize))
+}
+
+func (c *chunkCache) get(key chunkKey) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		atomic.AddUint64(&c.misses, 1)
+		return nil, false
+	}
+	atomic.AddUint64(&c.hits, 1)
+	atomic.AddUint64(&c.bytesServed, uint64(len(e.data)))
+	c.moveToFront(e)
+	return e.data, true
+}
+
+func (c *chunkCache) put(key chunkKey, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		c.totalBytes += int64(len(data)) - int64(len(e.data))
+		e.data = data
+		c.moveToFront(e)
+	} else {
+		e = &chunkEntry{key: key, data: data}
+		c.entries[key] = e
+		c.pushFront(e)
+		c.totalBytes += int64(len(data))
+	}
+	for c.opts.MaxBytes > 0 && c.totalBytes > int64(c.opts.MaxBytes) && c.tail != nil {
+		c.evictOldest()
+	}
+}
+
+// invalidateInode purges every cached chunk for inode, used by Unlink, by
+// Rename for an overwritten destination, and by writes that change file
+// size. Directories hold no chunks, so Rmdir has nothing to purge.
+func (c *chunkCache) invalidateInode(inode int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, e := range c.entries {
+		if key.inode == inode {
+			c.removeLocked(key, e)
+		}
+	}
+}
+
+// invalidateRange drops cached chunks overlapping [offset, offset+n) for
+// inode, used when a write updates part of a file in place.
+func (c *chunkCache) invalidateRange(inode int64, offset, n int64) {
+	if n <= 0 {
+		return
+	}
+	first := int(offset / int64(c.opts.ChunkSize))
+	last := int((offset + n - 1) / int64(c.opts.ChunkSize))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for idx := first; idx <= last; idx++ {
+		key := chunkKey{inode: inode, index: idx}
+		if e, ok := c.entries[key]; ok {
+			c.removeLocked(key, e)
+		}
+	}
+}
+
+// truncateInode clears cached data for every chunk at or beyond the chunk
+// containing newSize, since that tail data is now stale.
+func (c *chunkCache) truncateInode(inode int64, newSize int64) {
+	firstStale := int(newSize / int64(c.opts.ChunkSize))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, e := range c.entries {
+		if key.inode == inode && key.index >= firstStale {
+			c.removeLocked(key, e)
+		}
+	}
+}
+
+func (c *chunkCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[chunkKey]*chunkEntry)
+	c.head, c.tail = nil, nil
+	c.totalBytes = 0
+}
+
+func (c *chunkCache) stats() (hits, misses, bytesServed, resident uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return atomic.LoadUint64(&c.hits), atomic.LoadUint64(&c.misses), atomic.LoadUint64(&c.bytesServed), uint64(c.totalBytes)
+}
+
+// --- intrusive LRU list + removal, mu must be held ---
+
+func (c *chunkCache) removeLocked(key chunkKey, e *chunkEntry) {
+	c.remove(e)
+	delete(c.entries, key)
+	c.totalBytes -= int64(len(e.data))
+}
+
+func (c *chunkCache) pushFront(e *chunkEntry) {
+	e.prev, e.next = nil, c.head
+	if c.head != nil {
+		c.head.prev = e
+	}
+	c.head = e
+	if c.tail == nil {
+		c.tail = e
+	}
+}
+
+func (c *chunkCache) remove(e *chunkEntry) {
+	if e.prev != nil {
+		e.prev.next = e.next
+	} else {
+		c.head = e.next
+	}
+	if e.next != nil {
+		e.next.prev = e.prev
+	} else {
+		c.tail = e.prev
+	}
+	e.prev, e.next = nil, nil
+}
+
+func (c *chunkCache) moveToFront(e *chunkEntry) {
+	if c.head == e {
+		return
+	}
+	c.remove(e)
+	c.pushFront(e)
+}
+
+func (c *chunkCache) evictOldest() {
+	if c.tail == nil {
+		return
+	}
+	c.removeLocked(c.tail.key, c.tail)
+}
@@ -0,0 +1,164 @@
+package agentfs
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func setupTestDBWithChunkCache(t *testing.T, chunkSize int) *AgentFS {
+	t.Helper()
+	ctx := context.Background()
+	afs, err := Open(ctx, AgentFSOptions{
+		Path: ":memory:",
+		Cache: CacheOptions{
+			Enabled:    true,
+			MaxEntries: 1000,
+			ChunkSize:  chunkSize,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to open AgentFS with chunk cache: %v", err)
+	}
+	return afs
+}
+
+func TestChunkCache_HitsAndMisses(t *testing.T) {
+	afs := setupTestDBWithChunkCache(t, 4)
+	defer afs.Close()
+	ctx := context.Background()
+
+	content := []byte("0123456789abcdef") // 16 bytes = 4 chunks of 4
+	if err := afs.FS.WriteFile(ctx, "/f.bin", content, 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	buf := make([]byte, 4)
+	if _, err := afs.FS.ReadAt(ctx, "/f.bin", buf, 0); err != nil {
+		t.Fatalf("ReadAt failed: %v", err)
+	}
+	if !bytes.Equal(buf, []byte("0123")) {
+		t.Errorf("ReadAt = %q, want %q", buf, "0123")
+	}
+
+	stats := afs.FS.CacheStats()
+	if stats.ChunkMisses == 0 {
+		t.Error("Expected a chunk miss on first read")
+	}
+	initialMisses := stats.ChunkMisses
+
+	// Re-read the same chunk: should hit.
+	if _, err := afs.FS.ReadAt(ctx, "/f.bin", buf, 0); err != nil {
+		t.Fatalf("ReadAt failed: %v", err)
+	}
+	stats = afs.FS.CacheStats()
+	if stats.ChunkHits == 0 {
+		t.Error("Expected a chunk hit on second read of the same range")
+	}
+	if stats.ChunkMisses != initialMisses {
+		t.Error("Expected no additional misses on repeat read")
+	}
+}
+
+func TestChunkCache_PartialResidencyFetchesOnlyMissing(t *testing.T) {
+	afs := setupTestDBWithChunkCache(t, 4)
+	defer afs.Close()
+	ctx := context.Background()
+
+	content := []byte("0123456789abcdef")
+	if err := afs.FS.WriteFile(ctx, "/f.bin", content, 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	// Warm only the first chunk.
+	buf := make([]byte, 4)
+	afs.FS.ReadAt(ctx, "/f.bin", buf, 0)
+
+	stats := afs.FS.CacheStats()
+	missesAfterFirst := stats.ChunkMisses
+
+	// Read a range spanning the cached first chunk and the uncached second.
+	full := make([]byte, 8)
+	if _, err := afs.FS.ReadAt(ctx, "/f.bin", full, 0); err != nil {
+		t.Fatalf("ReadAt failed: %v", err)
+	}
+	if !bytes.Equal(full, []byte("01234567")) {
+		t.Errorf("ReadAt = %q, want %q", full, "01234567")
+	}
+
+	stats = afs.FS.CacheStats()
+	if stats.ChunkHits == 0 {
+		t.Error("Expected a hit for the already-resident chunk")
+	}
+	if stats.ChunkMisses != missesAfterFirst+1 {
+		t.Errorf("ChunkMisses = %d, want exactly one additional miss for the new chunk", stats.ChunkMisses-missesAfterFirst)
+	}
+}
+
+func TestChunkCache_InvalidationOnUnlink(t *testing.T) {
+	afs := setupTestDBWithChunkCache(t, 4)
+	defer afs.Close()
+	ctx := context.Background()
+
+	afs.FS.WriteFile(ctx, "/gone.bin", []byte("0123456789ab"), 0o644)
+	buf := make([]byte, 4)
+	afs.FS.ReadAt(ctx, "/gone.bin", buf, 0)
+
+	if err := afs.FS.Unlink(ctx, "/gone.bin"); err != nil {
+		t.Fatalf("Unlink failed: %v", err)
+	}
+
+	stats := afs.FS.CacheStats()
+	if stats.ResidentBytes != 0 {
+		t.Errorf("ResidentBytes = %d, want 0 after Unlink", stats.ResidentBytes)
+	}
+}
+
+func TestChunkCache_TruncateClearsTailChunks(t *testing.T) {
+	afs := setupTestDBWithChunkCache(t, 4)
+	defer afs.Close()
+	ctx := context.Background()
+
+	afs.FS.WriteFile(ctx, "/t.bin", []byte("0123456789abcdef"), 0o644)
+	full := make([]byte, 16)
+	afs.FS.ReadAt(ctx, "/t.bin", full, 0) // warm all 4 chunks
+
+	if err := afs.FS.Truncate(ctx, "/t.bin", 5); err != nil {
+		t.Fatalf("Truncate failed: %v", err)
+	}
+
+	buf := make([]byte, 5)
+	n, err := afs.FS.ReadAt(ctx, "/t.bin", buf, 0)
+	if err != nil {
+		t.Fatalf("ReadAt after truncate failed: %v", err)
+	}
+	if string(buf[:n]) != "01234" {
+		t.Errorf("post-truncate read = %q, want %q", buf[:n], "01234")
+	}
+}
+
+func TestChunkCache_MaxBytesEviction(t *testing.T) {
+	ctx := context.Background()
+	afs, err := Open(ctx, AgentFSOptions{
+		Path: ":memory:",
+		Cache: CacheOptions{
+			Enabled:    true,
+			MaxEntries: 1000,
+			ChunkSize:  4,
+			MaxBytes:   8, // only 2 chunks resident at once
+		},
+	})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer afs.Close()
+
+	afs.FS.WriteFile(ctx, "/big.bin", []byte("0123456789abcdef"), 0o644)
+	full := make([]byte, 16)
+	afs.FS.ReadAt(ctx, "/big.bin", full, 0)
+
+	stats := afs.FS.CacheStats()
+	if stats.ResidentBytes > 8 {
+		t.Errorf("ResidentBytes = %d, want <= 8 (MaxBytes)", stats.ResidentBytes)
+	}
+}
@@ -0,0 +1,30 @@
+package agentfs
+
+import "errors"
+
+// Sentinel errors returned by FS operations. Callers should compare against
+// these with errors.Is rather than string-matching, since wrapped variants
+// may carry additional path context.
+var (
+	ErrNotExist = errors.New("agentfs: no such file or directory")
+	ErrExist    = errors.New("agentfs: file already exists")
+	ErrNotDir   = errors.New("agentfs: not a directory")
+	ErrIsDir    = errors.New("agentfs: is a directory")
+	ErrNotEmpty = errors.New("agentfs: directory not empty")
+	ErrClosed   = errors.New("agentfs: filesystem is closed")
+
+	// ErrDirtyBufferFull is returned by writeback-mode writes when the
+	// configured WritebackMaxDirty budget is exhausted. Callers should
+	// retry after the background flusher has made progress.
+	ErrDirtyBufferFull = errors.New("agentfs: writeback dirty buffer full")
+)
+
+// IsNotExist reports whether err indicates a missing path.
+func IsNotExist(err error) bool {
+	return errors.Is(err, ErrNotExist)
+}
+
+// IsExist reports whether err indicates a path already exists.
+func IsExist(err error) bool {
+	return errors.Is(err, ErrExist)
+}
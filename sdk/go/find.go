@@ -0,0 +1,265 @@
+package agentfs
+
+import (
+	"context"
+	"io/fs"
+	"path"
+	"regexp"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// FindQuery describes a filtered, recursive directory traversal for
+// FS.Find. The zero value matches every path reachable from Root.
+type FindQuery struct {
+	// Root is the directory to start from. Defaults to "/".
+	Root string
+
+	// Glob, if set, is matched against each candidate's base name with
+	// path.Match.
+	Glob string
+
+	// Regex, if set, is matched against each candidate's full path.
+	Regex *regexp.Regexp
+
+	// MtimeAfter and MtimeBefore bound matches to this modification time
+	// range. A zero time.Time leaves that bound open.
+	MtimeAfter  time.Time
+	MtimeBefore time.Time
+
+	// MinSize and MaxSize bound matches by size in bytes. A zero MaxSize
+	// leaves the upper bound open.
+	MinSize int64
+	MaxSize int64
+
+	// ModeMask, if nonzero, requires mode&ModeMask == ModeMask.
+	ModeMask fs.FileMode
+
+	// Prune, if set, is called with the path of each directory before it
+	// is descended into; returning true skips the whole subtree.
+	Prune func(path string) bool
+
+	// Workers bounds traversal concurrency. Defaults to runtime.NumCPU().
+	Workers int
+}
+
+func (q *FindQuery) matches(p string, info fs.FileInfo) bool {
+	if q.Glob != "" {
+		ok, err := path.Match(q.Glob, path.Base(p))
+		if err != nil || !ok {
+			return false
+		}
+	}
+	if q.Regex != nil && !q.Regex.MatchString(p) {
+		return false
+	}
+	if !q.MtimeAfter.IsZero() && info.ModTime().Before(q.MtimeAfter) {
+		return false
+	}
+	if !q.MtimeBefore.IsZero() && info.ModTime().After(q.MtimeBefore) {
+		return false
+	}
+	if info.Size() < q.MinSize {
+		return false
+	}
+	if q.MaxSize != 0 && info.Size() > q.MaxSize {
+		return false
+	}
+	if q.ModeMask != 0 && info.Mode()&q.ModeMask != q.ModeMask {
+		return false
+	}
+	return true
+}
+
+// Find performs a parallel, cache-backed traversal of the tree rooted at
+// q.Root, returning every path matching q. Directory listings are cached
+// per inode and invalidated automatically by the same mutation hooks that
+// invalidate the path cache, so a repeat Find after no writes serves every
+// directory listing from cache. Each directory's path is still resolved
+// through FS.resolve, which goes to the store when the path cache is
+// disabled.
+func (f *FS) Find(ctx context.Context, q FindQuery) ([]string, error) {
+	root := q.Root
+	if root == "" {
+		root = "/"
+	}
+	workers := q.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var results []string
+	var firstErr error
+	var errOnce sync.Once
+	setErr := func(err error) {
+		if err != nil {
+			errOnce.Do(func() { firstErr = err })
+		}
+	}
+
+	var walk func(dirPath string)
+	walk = func(dirPath string) {
+		defer wg.Done()
+		select {
+		case <-ctx.Done():
+			setErr(ctx.Err())
+			return
+		default:
+		}
+
+		entries, err := f.listDirCached(ctx, dirPath)
+		if err != nil {
+			setErr(err)
+			return
+		}
+		for _, e := range entries {
+			childPath := path.Join(dirPath, e.Name)
+			info := newFileInfo(e)
+			if q.matches(childPath, info) {
+				mu.Lock()
+				results = append(results, childPath)
+				mu.Unlock()
+			}
+			if e.IsDir {
+				if q.Prune != nil && q.Prune(childPath) {
+					continue
+				}
+				wg.Add(1)
+				// A blocking acquire here would deadlock: this goroutine may
+				// itself be holding the only free slot, so it falls back to
+				// walking the child inline rather than waiting for one to
+				// free up.
+				select {
+				case <-ctx.Done():
+					wg.Done()
+					setErr(ctx.Err())
+				case sem <- struct{}{}:
+					go func(p string) {
+						defer func() { <-sem }()
+						walk(p)
+					}(childPath)
+				default:
+					walk(childPath)
+				}
+			}
+		}
+	}
+
+	wg.Add(1)
+	sem <- struct{}{}
+	go func() {
+		defer func() { <-sem }()
+		walk(root)
+	}()
+	wg.Wait()
+
+	return results, firstErr
+}
+
+// listDirCached returns the children of dirPath, served from the
+// directory-listing cache when its generation counter still matches.
+func (f *FS) listDirCached(ctx context.Context, dirPath string) ([]*inode, error) {
+	dir, err := f.resolve(ctx, dirPath)
+	if err != nil {
+		return nil, err
+	}
+	if !dir.IsDir {
+		return nil, ErrNotDir
+	}
+	if f.dirCache == nil {
+		return f.store.Children(ctx, dir.ID)
+	}
+	if entries, ok := f.dirCache.get(dir.ID); ok {
+		return entries, nil
+	}
+	fetchGen := f.dirCache.currentGen(dir.ID)
+	entries, err := f.store.Children(ctx, dir.ID)
+	if err != nil {
+		return nil, err
+	}
+	f.dirCache.put(dir.ID, fetchGen, entries)
+	return entries, nil
+}
+
+// dirListing is one cached directory's children, valid as long as
+// generation matches the live counter for that inode.
+type dirListing struct {
+	generation uint64
+	entries    []*inode
+	expires    time.Time
+}
+
+// dirListingCache caches directory children keyed by inode ID. Each inode
+// has a generation counter that any mutation touching that directory
+// (WriteFile, Unlink, Rmdir, Rename, MkdirAll) bumps, so a stale listing is
+// detected and refetched rather than served incorrectly.
+type dirListingCache struct {
+	mu       sync.RWMutex
+	gen      map[int64]uint64
+	listings map[int64]*dirListing
+
+	hits, misses uint64
+}
+
+func newDirListingCache() *dirListingCache {
+	return &dirListingCache{
+		gen:      make(map[int64]uint64),
+		listings: make(map[int64]*dirListing),
+	}
+}
+
+func (c *dirListingCache) currentGen(id int64) uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.gen[id]
+}
+
+// bump increments id's generation counter, invalidating any cached listing
+// for it.
+func (c *dirListingCache) bump(id int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen[id]++
+	delete(c.listings, id)
+}
+
+func (c *dirListingCache) get(id int64) ([]*inode, bool) {
+	c.mu.RLock()
+	l, ok := c.listings[id]
+	gen := c.gen[id]
+	c.mu.RUnlock()
+	if !ok || l.generation != gen {
+		atomic.AddUint64(&c.misses, 1)
+		return nil, false
+	}
+	atomic.AddUint64(&c.hits, 1)
+	return l.entries, true
+}
+
+// put CAS-installs entries for id: fetchGen is the generation the caller
+// observed before fetching entries from the store. If the live generation
+// has moved on since then, a mutation raced with the fetch and the result is
+// discarded rather than resurrecting a listing that may already be stale.
+func (c *dirListingCache) put(id int64, fetchGen uint64, entries []*inode) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.gen[id] != fetchGen {
+		return
+	}
+	c.listings[id] = &dirListing{generation: fetchGen, entries: entries, expires: time.Now()}
+}
+
+func (c *dirListingCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.listings = make(map[int64]*dirListing)
+}
+
+func (c *dirListingCache) stats() (hits, misses uint64) {
+	return atomic.LoadUint64(&c.hits), atomic.LoadUint64(&c.misses)
+}
@@ -0,0 +1,144 @@
+package agentfs
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+)
+
+func setupTestDBWithTree(t *testing.T) *AgentFS {
+	t.Helper()
+	ctx := context.Background()
+	afs, err := Open(ctx, AgentFSOptions{Path: ":memory:"})
+	if err != nil {
+		t.Fatalf("Failed to open AgentFS: %v", err)
+	}
+	afs.FS.MkdirAll(ctx, "/src/pkg", 0o755)
+	afs.FS.WriteFile(ctx, "/src/main.go", []byte("package main"), 0o644)
+	afs.FS.WriteFile(ctx, "/src/pkg/lib.go", []byte("package pkg"), 0o644)
+	afs.FS.WriteFile(ctx, "/README.md", []byte("# readme"), 0o644)
+	return afs
+}
+
+func TestFind_GlobMatchesAcrossTree(t *testing.T) {
+	afs := setupTestDBWithTree(t)
+	defer afs.Close()
+	ctx := context.Background()
+
+	results, err := afs.FS.Find(ctx, FindQuery{Glob: "*.go"})
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	sort.Strings(results)
+	want := []string{"/src/main.go", "/src/pkg/lib.go"}
+	sort.Strings(want)
+	if len(results) != len(want) {
+		t.Fatalf("Find results = %v, want %v", results, want)
+	}
+	for i := range want {
+		if results[i] != want[i] {
+			t.Errorf("Find results = %v, want %v", results, want)
+			break
+		}
+	}
+}
+
+func TestFind_PruneSkipsSubtree(t *testing.T) {
+	afs := setupTestDBWithTree(t)
+	defer afs.Close()
+	ctx := context.Background()
+
+	results, err := afs.FS.Find(ctx, FindQuery{
+		Glob:  "*.go",
+		Prune: func(p string) bool { return p == "/src/pkg" },
+	})
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	for _, r := range results {
+		if r == "/src/pkg/lib.go" {
+			t.Errorf("Find returned %q despite Prune on its directory", r)
+		}
+	}
+}
+
+func TestFind_SizeAndMtimeFilters(t *testing.T) {
+	afs := setupTestDBWithTree(t)
+	defer afs.Close()
+	ctx := context.Background()
+
+	results, err := afs.FS.Find(ctx, FindQuery{MinSize: 100})
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("Find with MinSize:100 = %v, want none (all seed files are small)", results)
+	}
+
+	future := time.Now().Add(time.Hour)
+	results, err = afs.FS.Find(ctx, FindQuery{MtimeAfter: future})
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("Find with future MtimeAfter = %v, want none", results)
+	}
+}
+
+func TestFind_CacheHitsOnRepeatQuery(t *testing.T) {
+	afs := setupTestDBWithTree(t)
+	defer afs.Close()
+	ctx := context.Background()
+
+	if _, err := afs.FS.Find(ctx, FindQuery{Glob: "*.go"}); err != nil {
+		t.Fatalf("first Find failed: %v", err)
+	}
+	if _, err := afs.FS.Find(ctx, FindQuery{Glob: "*.go"}); err != nil {
+		t.Fatalf("second Find failed: %v", err)
+	}
+
+	hits, _ := afs.FS.dirCache.stats()
+	if hits == 0 {
+		t.Error("Expected directory-listing cache hits on repeat Find")
+	}
+}
+
+func TestFind_InvalidatesOnWrite(t *testing.T) {
+	afs := setupTestDBWithTree(t)
+	defer afs.Close()
+	ctx := context.Background()
+
+	if _, err := afs.FS.Find(ctx, FindQuery{Glob: "*.go"}); err != nil {
+		t.Fatalf("first Find failed: %v", err)
+	}
+	if err := afs.FS.WriteFile(ctx, "/src/new.go", []byte("package src"), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	results, err := afs.FS.Find(ctx, FindQuery{Glob: "*.go"})
+	if err != nil {
+		t.Fatalf("second Find failed: %v", err)
+	}
+	found := false
+	for _, r := range results {
+		if r == "/src/new.go" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Find results = %v, missing newly written /src/new.go", results)
+	}
+}
+
+func TestDirListingCache_PutDiscardsStaleFetch(t *testing.T) {
+	c := newDirListingCache()
+
+	fetchGen := c.currentGen(1)
+	c.bump(1) // simulates a write landing between the store fetch and put
+	c.put(1, fetchGen, []*inode{{Name: "stale"}})
+
+	if _, ok := c.get(1); ok {
+		t.Error("get() returned a hit for a listing that raced with a concurrent bump")
+	}
+}
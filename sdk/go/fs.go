@@ -0,0 +1,406 @@
+package agentfs
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"path"
+)
+
+// FS is the filesystem-facing API of an AgentFS handle. All methods are
+// safe for concurrent use. When caching is enabled, path resolution is
+// served from an in-memory LRU in front of the store; every mutation
+// invalidates the affected paths before returning.
+type FS struct {
+	store    Backend
+	cache    *pathCache       // nil when caching is disabled
+	chunks   *chunkCache      // nil when chunk caching is disabled
+	dirCache *dirListingCache // backs Find; always populated
+}
+
+func newFS(store Backend, opts CacheOptions) *FS {
+	f := &FS{store: store, dirCache: newDirListingCache()}
+	if opts.Enabled {
+		f.cache = newPathCache(opts)
+		if opts.ChunkSize == 0 {
+			opts.ChunkSize = defaultChunkSize
+		}
+		f.chunks = newChunkCache(opts)
+	}
+	return f
+}
+
+func clean(p string) string {
+	return path.Clean("/" + p)
+}
+
+func (f *FS) resolve(ctx context.Context, p string) (*inode, error) {
+	p = clean(p)
+	if f.cache != nil {
+		if ino, ok := f.cache.get(p); ok {
+			return ino, nil
+		}
+	}
+	ino, err := f.store.Resolve(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	if f.cache != nil {
+		f.cache.put(p, ino)
+	}
+	return ino, nil
+}
+
+func (f *FS) invalidate(p string) {
+	if f.cache != nil {
+		f.cache.invalidate(clean(p))
+	}
+}
+
+// bumpParentGen notifies the directory-listing cache that p's parent
+// directory changed, so a cached Find traversal refetches it instead of
+// serving stale children.
+func (f *FS) bumpParentGen(ctx context.Context, p string) {
+	dir := path.Dir(clean(p))
+	if ino, err := f.store.Resolve(ctx, dir); err == nil {
+		f.dirCache.bump(ino.ID)
+	}
+}
+
+// Stat returns file information for path.
+func (f *FS) Stat(ctx context.Context, path string) (fs.FileInfo, error) {
+	ino, err := f.resolve(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return newFileInfo(ino), nil
+}
+
+// WriteFile creates or overwrites the file at path with data. In writeback
+// mode the write lands in the dirty buffer and this returns as soon as it
+// is queued; otherwise it persists synchronously.
+func (f *FS) WriteFile(ctx context.Context, path string, data []byte, mode fs.FileMode) error {
+	if f.writebackEnabled() {
+		f.bumpParentGen(ctx, path)
+		return f.bufferWrite(ctx, path, data, mode)
+	}
+	persisted, err := f.store.CreateFile(ctx, path, data, mode)
+	if err != nil {
+		return err
+	}
+	if f.chunks != nil {
+		f.chunks.invalidateInode(persisted.ID)
+	}
+	f.invalidate(path)
+	f.bumpParentGen(ctx, path)
+	return nil
+}
+
+// Write replaces the region [offset, offset+len(data)) of the file at path,
+// extending it if necessary. Like WriteFile, it is buffered in writeback
+// mode.
+func (f *FS) Write(ctx context.Context, path string, offset int64, data []byte) error {
+	cur, mode, err := f.currentContents(ctx, path)
+	if err != nil {
+		return err
+	}
+	end := offset + int64(len(data))
+	grew := end > int64(len(cur))
+	if grew {
+		grown := make([]byte, end)
+		copy(grown, cur)
+		cur = grown
+	}
+	copy(cur[offset:], data)
+
+	if f.writebackEnabled() {
+		f.bumpParentGen(ctx, path)
+		return f.bufferWrite(ctx, path, cur, mode)
+	}
+	persisted, err := f.store.CreateFile(ctx, path, cur, mode)
+	if err != nil {
+		return err
+	}
+	if f.chunks != nil {
+		// Growth changes the size of the old tail chunk, so the whole
+		// inode is purged; an in-place write only drops the written range.
+		if grew {
+			f.chunks.invalidateInode(persisted.ID)
+		} else {
+			f.chunks.invalidateRange(persisted.ID, offset, int64(len(data)))
+		}
+	}
+	f.invalidate(path)
+	f.bumpParentGen(ctx, path)
+	return nil
+}
+
+// Truncate changes the size of the file at path, zero-filling any growth.
+func (f *FS) Truncate(ctx context.Context, path string, size int64) error {
+	cur, mode, err := f.currentContents(ctx, path)
+	if err != nil {
+		return err
+	}
+	oldSize := int64(len(cur))
+	if size <= oldSize {
+		cur = cur[:size]
+	} else {
+		grown := make([]byte, size)
+		copy(grown, cur)
+		cur = grown
+	}
+
+	if f.writebackEnabled() {
+		f.bumpParentGen(ctx, path)
+		return f.bufferWrite(ctx, path, cur, mode)
+	}
+	persisted, err := f.store.CreateFile(ctx, path, cur, mode)
+	if err != nil {
+		return err
+	}
+	if f.chunks != nil {
+		// On growth the chunk holding the old end of file is stale too.
+		staleFrom := size
+		if oldSize < staleFrom {
+			staleFrom = oldSize
+		}
+		f.chunks.truncateInode(persisted.ID, staleFrom)
+	}
+	f.invalidate(path)
+	f.bumpParentGen(ctx, path)
+	return nil
+}
+
+// currentContents returns the bytes a reader would see right now: the
+// buffered dirty copy if one is pending, otherwise the persisted contents.
+func (f *FS) currentContents(ctx context.Context, path string) ([]byte, fs.FileMode, error) {
+	if f.writebackEnabled() {
+		if ino, ok := f.dirtyByPath(path); ok {
+			return ino.Data, ino.Mode, nil
+		}
+	}
+	ino, err := f.resolve(ctx, path)
+	if err != nil {
+		if IsNotExist(err) {
+			return nil, 0o644, nil
+		}
+		return nil, 0, err
+	}
+	if ino.IsDir {
+		return nil, 0, ErrIsDir
+	}
+	return ino.Data, ino.Mode, nil
+}
+
+// Sync force-flushes any buffered writeback data to the store.
+func (f *FS) Sync(ctx context.Context) error {
+	if !f.writebackEnabled() {
+		return nil
+	}
+	return f.flushAll(ctx)
+}
+
+// ReadFile returns the full contents of the file at path.
+func (f *FS) ReadFile(ctx context.Context, path string) ([]byte, error) {
+	ino, err := f.resolve(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if ino.IsDir {
+		return nil, ErrIsDir
+	}
+	out := make([]byte, len(ino.Data))
+	copy(out, ino.Data)
+	return out, nil
+}
+
+// ReadAt reads into buf starting at offset, like io.ReaderAt: it returns
+// io.EOF once offset reaches the end of the file, possibly alongside a
+// short read. When the chunk cache is enabled, fully-resident ranges are
+// served without touching the store; partially-resident ranges fetch only
+// the missing chunks.
+func (f *FS) ReadAt(ctx context.Context, path string, buf []byte, offset int64) (int, error) {
+	ino, err := f.resolve(ctx, path)
+	if err != nil {
+		return 0, err
+	}
+	if ino.IsDir {
+		return 0, ErrIsDir
+	}
+	isDirty := false
+	if f.writebackEnabled() {
+		if dirty, ok := f.dirtyByPath(path); ok {
+			ino = dirty
+			isDirty = true
+		}
+	}
+	if offset >= ino.Size {
+		return 0, io.EOF
+	}
+	n := int64(len(buf))
+	if offset+n > ino.Size {
+		n = ino.Size - offset
+	}
+	// A pending writeback buffer is served directly so readers always see
+	// their own writes; the chunk cache only ever holds persisted content.
+	if f.chunks == nil || isDirty {
+		copy(buf, ino.Data[offset:offset+n])
+		if n < int64(len(buf)) {
+			return int(n), io.EOF
+		}
+		return int(n), nil
+	}
+	if err := f.readChunked(ctx, ino, buf[:n], offset); err != nil {
+		return 0, err
+	}
+	if n < int64(len(buf)) {
+		return int(n), io.EOF
+	}
+	return int(n), nil
+}
+
+// readChunked fills out with ino's contents over [offset, offset+len(out)),
+// serving resident chunks from the cache and fetching the rest from the
+// store one chunk at a time.
+func (f *FS) readChunked(ctx context.Context, ino *inode, out []byte, offset int64) error {
+	size := f.chunks.opts.ChunkSize
+	end := offset + int64(len(out))
+	for pos := offset; pos < end; {
+		idx := f.chunks.chunkIndex(pos)
+		chunkStart := int64(idx) * int64(size)
+		chunkLen := int64(size)
+		if chunkStart+chunkLen > ino.Size {
+			chunkLen = ino.Size - chunkStart
+		}
+		key := chunkKey{inode: ino.ID, index: idx}
+		data, ok := f.chunks.get(key)
+		if !ok {
+			var err error
+			data, err = f.store.ReadRange(ctx, ino.ID, chunkStart, chunkLen)
+			if err != nil {
+				return err
+			}
+			f.chunks.put(key, data)
+		}
+		withinChunk := pos - chunkStart
+		n := copy(out[pos-offset:], data[withinChunk:])
+		pos += int64(n)
+	}
+	return nil
+}
+
+// MkdirAll creates path and any missing parents, like os.MkdirAll.
+func (f *FS) MkdirAll(ctx context.Context, dirPath string, mode fs.FileMode) error {
+	dirPath = clean(dirPath)
+	if dirPath == "/" {
+		return nil
+	}
+	parts := splitPath(dirPath)
+	cur := "/"
+	for _, part := range parts {
+		cur = path.Join(cur, part)
+		if _, err := f.store.Mkdir(ctx, cur, mode|fs.ModeDir); err != nil && !IsExist(err) {
+			return err
+		}
+		f.bumpParentGen(ctx, cur)
+	}
+	f.invalidate(dirPath)
+	return nil
+}
+
+// Unlink removes the file at path.
+func (f *FS) Unlink(ctx context.Context, path string) error {
+	wasBuffered := false
+	if f.writebackEnabled() {
+		wasBuffered = f.dropDirty(path)
+	}
+	var id int64
+	if f.chunks != nil {
+		if ino, err := f.store.Resolve(ctx, path); err == nil {
+			id = ino.ID
+		}
+	}
+	if err := f.store.Unlink(ctx, path); err != nil {
+		if IsNotExist(err) && wasBuffered {
+			// Never made it past the dirty buffer, so the store never saw it.
+			f.invalidate(path)
+			return nil
+		}
+		return err
+	}
+	if f.chunks != nil && id != 0 {
+		f.chunks.invalidateInode(id)
+	}
+	f.invalidate(path)
+	f.bumpParentGen(ctx, path)
+	return nil
+}
+
+// Rmdir removes the empty directory at path.
+func (f *FS) Rmdir(ctx context.Context, path string) error {
+	if err := f.store.Rmdir(ctx, path); err != nil {
+		return err
+	}
+	f.invalidate(path)
+	f.bumpParentGen(ctx, path)
+	return nil
+}
+
+// Rename moves oldPath to newPath, overwriting newPath if present. Any
+// buffered writeback data for oldPath is flushed first so the rename in the
+// store sees up-to-date contents; any buffered write pending for newPath is
+// dropped, since it would otherwise flush later and clobber the rename.
+func (f *FS) Rename(ctx context.Context, oldPath, newPath string) error {
+	if f.writebackEnabled() {
+		if err := f.forceFlushPath(ctx, oldPath); err != nil {
+			return err
+		}
+		f.dropDirty(newPath)
+	}
+	var overwritten int64
+	if f.chunks != nil {
+		if ino, err := f.store.Resolve(ctx, newPath); err == nil {
+			overwritten = ino.ID
+		}
+	}
+	if err := f.store.Rename(ctx, oldPath, newPath); err != nil {
+		return err
+	}
+	if f.chunks != nil && overwritten != 0 {
+		f.chunks.invalidateInode(overwritten)
+	}
+	f.invalidate(oldPath)
+	f.invalidate(newPath)
+	f.bumpParentGen(ctx, oldPath)
+	f.bumpParentGen(ctx, newPath)
+	return nil
+}
+
+// CacheStats returns a snapshot of cache counters, or nil if caching is
+// disabled.
+func (f *FS) CacheStats() *CacheStats {
+	if f.cache == nil {
+		return nil
+	}
+	stats := f.cache.stats()
+	if f.chunks != nil {
+		hits, misses, served, resident := f.chunks.stats()
+		stats.ChunkHits = hits
+		stats.ChunkMisses = misses
+		stats.BytesServedFromCache = served
+		stats.ResidentBytes = resident
+	}
+	stats.DirListingHits, stats.DirListingMisses = f.dirCache.stats()
+	return stats
+}
+
+// ClearCache discards all cached path entries and chunk cache contents.
+func (f *FS) ClearCache() {
+	if f.cache != nil {
+		f.cache.clear()
+	}
+	if f.chunks != nil {
+		f.chunks.clear()
+	}
+	f.dirCache.clear()
+}
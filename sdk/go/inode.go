@@ -0,0 +1,46 @@
+package agentfs
+
+import (
+	"io/fs"
+	"time"
+)
+
+// inode is the in-memory view of a row in the inodes table. Paths are never
+// stored directly; every inode is reached by walking dirents from the root,
+// which is what lets Rename reparent a subtree by touching a single row.
+type inode struct {
+	ID       int64
+	ParentID int64
+	Name     string
+	Mode     fs.FileMode
+	Size     int64
+	Mtime    time.Time
+	IsDir    bool
+	Data     []byte
+}
+
+// fileInfo adapts an inode to fs.FileInfo.
+type fileInfo struct {
+	name  string
+	size  int64
+	mode  fs.FileMode
+	mtime time.Time
+	isDir bool
+}
+
+func (fi *fileInfo) Name() string       { return fi.name }
+func (fi *fileInfo) Size() int64        { return fi.size }
+func (fi *fileInfo) Mode() fs.FileMode  { return fi.mode }
+func (fi *fileInfo) ModTime() time.Time { return fi.mtime }
+func (fi *fileInfo) IsDir() bool        { return fi.isDir }
+func (fi *fileInfo) Sys() any           { return nil }
+
+func newFileInfo(ino *inode) *fileInfo {
+	return &fileInfo{
+		name:  ino.Name,
+		size:  ino.Size,
+		mode:  ino.Mode,
+		mtime: ino.Mtime,
+		isDir: ino.IsDir,
+	}
+}
@@ -0,0 +1,269 @@
+package agentfs
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// ioFSAdapter adapts an *FS to the io/fs.FS family of interfaces. It is used
+// internally wherever agentfs needs to treat itself as a generic read-only
+// filesystem (for example, as an OverlayFS lower layer); FS.IoFS exposes the
+// same adapter publicly. It implements fs.FS, fs.ReadDirFS, fs.StatFS,
+// fs.ReadFileFS, fs.SubFS, and fs.GlobFS.
+type ioFSAdapter struct {
+	fs  *FS
+	ctx context.Context
+
+	// root is the AgentFS path corresponding to this adapter's ".", so Sub
+	// can return a new adapter scoped to a subtree without copying data.
+	root string
+}
+
+func newIoFSAdapter(f *FS) *ioFSAdapter {
+	return &ioFSAdapter{fs: f, ctx: context.Background(), root: "/"}
+}
+
+// IoFS returns f as a standard io/fs.FS, rooted at "/". The returned value
+// also implements fs.ReadDirFS, fs.StatFS, fs.ReadFileFS, fs.SubFS, and
+// fs.GlobFS, so it works with io/fs-consuming code such as http.FS,
+// text/template.ParseFS, and fstest.TestFS.
+func (f *FS) IoFS() fs.FS {
+	return newIoFSAdapter(f)
+}
+
+// toAgentPath converts an io/fs name ("." for root, no leading slash,
+// relative to a.root) to an absolute AgentFS path.
+func (a *ioFSAdapter) toAgentPath(name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return a.root, nil
+	}
+	return path.Join(a.root, name), nil
+}
+
+func wrapPathError(op, name string, err error) error {
+	if err == nil {
+		return nil
+	}
+	switch {
+	case IsNotExist(err):
+		err = fs.ErrNotExist
+	case strings.Contains(err.Error(), "not a directory"):
+		// already specific enough; fall through
+	}
+	return &fs.PathError{Op: op, Path: name, Err: err}
+}
+
+func (a *ioFSAdapter) Open(name string) (fs.File, error) {
+	p, err := a.toAgentPath(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := a.fs.Stat(a.ctx, p)
+	if err != nil {
+		return nil, wrapPathError("open", name, err)
+	}
+	if info.IsDir() {
+		entries, err := a.ReadDir(name)
+		if err != nil {
+			return nil, err
+		}
+		return &ioDir{name: name, info: info, entries: entries}, nil
+	}
+	data, err := a.fs.ReadFile(a.ctx, p)
+	if err != nil {
+		return nil, wrapPathError("open", name, err)
+	}
+	return &ioFile{info: info, r: bytes.NewReader(data)}, nil
+}
+
+func (a *ioFSAdapter) Stat(name string) (fs.FileInfo, error) {
+	p, err := a.toAgentPath(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := a.fs.Stat(a.ctx, p)
+	if err != nil {
+		return nil, wrapPathError("stat", name, err)
+	}
+	return info, nil
+}
+
+// ReadDir implements fs.ReadDirFS. Entries are sorted by filename, per the
+// io/fs.ReadDirFS contract, since the backing store makes no ordering
+// guarantee of its own.
+func (a *ioFSAdapter) ReadDir(name string) ([]fs.DirEntry, error) {
+	p, err := a.toAgentPath(name)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := a.fs.resolve(a.ctx, p)
+	if err != nil {
+		return nil, wrapPathError("readdir", name, err)
+	}
+	if !dir.IsDir {
+		return nil, wrapPathError("readdir", name, ErrNotDir)
+	}
+	children, err := a.fs.store.Children(a.ctx, dir.ID)
+	if err != nil {
+		return nil, wrapPathError("readdir", name, err)
+	}
+	entries := make([]fs.DirEntry, 0, len(children))
+	for _, c := range children {
+		entries = append(entries, fs.FileInfoToDirEntry(newFileInfo(c)))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// ReadFile implements fs.ReadFileFS.
+func (a *ioFSAdapter) ReadFile(name string) ([]byte, error) {
+	p, err := a.toAgentPath(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := a.fs.ReadFile(a.ctx, p)
+	if err != nil {
+		return nil, wrapPathError("readfile", name, err)
+	}
+	return data, nil
+}
+
+// Sub implements fs.SubFS, returning a new adapter scoped to dir.
+func (a *ioFSAdapter) Sub(dir string) (fs.FS, error) {
+	if dir == "." {
+		return a, nil
+	}
+	p, err := a.toAgentPath(dir)
+	if err != nil {
+		return nil, err
+	}
+	ino, err := a.fs.resolve(a.ctx, p)
+	if err != nil {
+		return nil, wrapPathError("sub", dir, err)
+	}
+	if !ino.IsDir {
+		return nil, wrapPathError("sub", dir, ErrNotDir)
+	}
+	return &ioFSAdapter{fs: a.fs, ctx: a.ctx, root: p}, nil
+}
+
+// Glob implements fs.GlobFS. It is a self-contained port of the algorithm
+// behind io/fs.Glob, duplicated here (rather than calling fs.Glob) because
+// that package dispatches straight back to GlobFS.Glob when the fsys
+// argument already implements it, which would recurse forever.
+func (a *ioFSAdapter) Glob(pattern string) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	if !hasGlobMeta(pattern) {
+		if _, err := a.Stat(pattern); err != nil {
+			return nil, nil
+		}
+		return []string{pattern}, nil
+	}
+
+	dir, file := path.Split(pattern)
+	dir = cleanGlobDir(dir)
+
+	if !hasGlobMeta(dir) {
+		return a.globDir(dir, file, nil)
+	}
+	if dir == pattern {
+		return nil, fs.ErrInvalid
+	}
+
+	dirs, err := a.Glob(dir)
+	if err != nil {
+		return nil, err
+	}
+	var matches []string
+	for _, d := range dirs {
+		matches, err = a.globDir(d, file, matches)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return matches, nil
+}
+
+func (a *ioFSAdapter) globDir(dir, pattern string, matches []string) ([]string, error) {
+	entries, err := a.ReadDir(dir)
+	if err != nil {
+		return matches, nil
+	}
+	for _, e := range entries {
+		ok, err := path.Match(pattern, e.Name())
+		if err != nil {
+			return matches, err
+		}
+		if ok {
+			matches = append(matches, path.Join(dir, e.Name()))
+		}
+	}
+	return matches, nil
+}
+
+func cleanGlobDir(dir string) string {
+	switch dir {
+	case "":
+		return "."
+	case "/":
+		return dir
+	default:
+		return dir[:len(dir)-1]
+	}
+}
+
+func hasGlobMeta(p string) bool {
+	return strings.ContainsAny(p, "*?[\\")
+}
+
+// ioFile implements fs.File for a regular file.
+type ioFile struct {
+	info fs.FileInfo
+	r    *bytes.Reader
+}
+
+func (f *ioFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *ioFile) Read(b []byte) (int, error) { return f.r.Read(b) }
+func (f *ioFile) Close() error               { return nil }
+
+// ioDir implements fs.File and fs.ReadDirFile for a directory.
+type ioDir struct {
+	name    string
+	info    fs.FileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *ioDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *ioDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: fs.ErrInvalid}
+}
+func (d *ioDir) Close() error { return nil }
+
+func (d *ioDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if n <= 0 {
+		rest := d.entries[d.offset:]
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if d.offset >= len(d.entries) {
+		return nil, io.EOF
+	}
+	end := d.offset + n
+	if end > len(d.entries) {
+		end = len(d.entries)
+	}
+	out := d.entries[d.offset:end]
+	d.offset = end
+	return out, nil
+}
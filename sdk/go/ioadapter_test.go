@@ -0,0 +1,141 @@
+package agentfs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func setupTestDBWithIoFS(t *testing.T) *AgentFS {
+	t.Helper()
+	ctx := context.Background()
+	afs, err := Open(ctx, AgentFSOptions{Path: ":memory:"})
+	if err != nil {
+		t.Fatalf("Failed to open AgentFS: %v", err)
+	}
+	afs.FS.MkdirAll(ctx, "/a/b", 0o755)
+	afs.FS.WriteFile(ctx, "/a/one.txt", []byte("one"), 0o644)
+	afs.FS.WriteFile(ctx, "/a/b/two.txt", []byte("two"), 0o644)
+	return afs
+}
+
+func TestIoFS_ConformsToFSTestTestFS(t *testing.T) {
+	afs := setupTestDBWithIoFS(t)
+	defer afs.Close()
+
+	if err := fstest.TestFS(afs.FS.IoFS(), "a/one.txt", "a/b/two.txt", "a/b"); err != nil {
+		t.Fatalf("fstest.TestFS failed: %v", err)
+	}
+}
+
+func TestIoFS_ReadFileAndStat(t *testing.T) {
+	afs := setupTestDBWithIoFS(t)
+	defer afs.Close()
+
+	iofs := afs.FS.IoFS()
+	rfs, ok := iofs.(fs.ReadFileFS)
+	if !ok {
+		t.Fatal("IoFS() does not implement fs.ReadFileFS")
+	}
+	data, err := rfs.ReadFile("a/one.txt")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if string(data) != "one" {
+		t.Errorf("ReadFile = %q, want %q", data, "one")
+	}
+
+	sfs, ok := iofs.(fs.StatFS)
+	if !ok {
+		t.Fatal("IoFS() does not implement fs.StatFS")
+	}
+	info, err := sfs.Stat("a/b")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if !info.IsDir() {
+		t.Error("Stat(\"a/b\").IsDir() = false, want true")
+	}
+}
+
+func TestIoFS_NotExistErrorsUnwrapCorrectly(t *testing.T) {
+	afs := setupTestDBWithIoFS(t)
+	defer afs.Close()
+
+	_, err := afs.FS.IoFS().Open("does/not/exist")
+	if err == nil {
+		t.Fatal("Open of missing path succeeded, want error")
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Open error = %v, want errors.Is(err, fs.ErrNotExist)", err)
+	}
+}
+
+func TestIoFS_SubScopesToDirectory(t *testing.T) {
+	afs := setupTestDBWithIoFS(t)
+	defer afs.Close()
+
+	sub, err := fs.Sub(afs.FS.IoFS(), "a")
+	if err != nil {
+		t.Fatalf("fs.Sub failed: %v", err)
+	}
+	data, err := fs.ReadFile(sub, "one.txt")
+	if err != nil {
+		t.Fatalf("ReadFile through Sub failed: %v", err)
+	}
+	if string(data) != "one" {
+		t.Errorf("ReadFile through Sub = %q, want %q", data, "one")
+	}
+}
+
+func TestIoFS_ReadDirIsSortedOverMemBackend(t *testing.T) {
+	ctx := context.Background()
+	afs, err := Open(ctx, AgentFSOptions{Backend: NewMemBackend()})
+	if err != nil {
+		t.Fatalf("Open with MemBackend failed: %v", err)
+	}
+	defer afs.Close()
+
+	// MemBackend.Children iterates a Go map, so insertion order tells us
+	// nothing; write in reverse-alphabetical order to make sure ReadDir's
+	// own sort is what's producing an alphabetical result, not luck.
+	for _, name := range []string{"zeta.txt", "mid.txt", "alpha.txt"} {
+		if err := afs.FS.WriteFile(ctx, "/"+name, []byte("x"), 0o644); err != nil {
+			t.Fatalf("WriteFile(%s) failed: %v", name, err)
+		}
+	}
+
+	entries, err := afs.FS.IoFS().(fs.ReadDirFS).ReadDir(".")
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	got := make([]string, len(entries))
+	for i, e := range entries {
+		got[i] = e.Name()
+	}
+	want := []string{"alpha.txt", "mid.txt", "zeta.txt"}
+	if len(got) != len(want) {
+		t.Fatalf("ReadDir = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ReadDir = %v, want %v (sorted by name)", got, want)
+			break
+		}
+	}
+}
+
+func TestIoFS_GlobMatchesAcrossDirectory(t *testing.T) {
+	afs := setupTestDBWithIoFS(t)
+	defer afs.Close()
+
+	matches, err := fs.Glob(afs.FS.IoFS(), "a/*.txt")
+	if err != nil {
+		t.Fatalf("Glob failed: %v", err)
+	}
+	if len(matches) != 1 || matches[0] != "a/one.txt" {
+		t.Errorf("Glob(a/*.txt) = %v, want [a/one.txt]", matches)
+	}
+}
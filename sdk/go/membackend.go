@@ -0,0 +1,243 @@
+package agentfs
+
+import (
+	"context"
+	"io/fs"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemBackend is a pure-Go, in-memory Backend with the same (parent_id, name)
+// addressing and semantics as sqliteStore, minus SQLite itself. It exists so
+// tests (and embedders) can get a hermetic, cgo-free filesystem without the
+// overhead of opening a database.
+type MemBackend struct {
+	mu     sync.Mutex
+	inodes map[int64]*inode
+	nextID int64
+}
+
+// NewMemBackend returns an initialized MemBackend with just a root
+// directory, ready to use.
+func NewMemBackend() *MemBackend {
+	m := &MemBackend{
+		inodes: make(map[int64]*inode),
+		nextID: rootInodeID + 1,
+	}
+	m.inodes[rootInodeID] = &inode{ID: rootInodeID, ParentID: rootInodeID, Name: "/", Mode: fs.ModeDir | 0o755, IsDir: true, Mtime: time.Now()}
+	return m
+}
+
+func (m *MemBackend) Close() error { return nil }
+
+// lookupChild returns the child of parent named name, or ErrNotExist. Caller
+// must hold m.mu. The returned *inode aliases backend state and must not
+// escape to callers outside this file; use cloneInode at the API boundary.
+func (m *MemBackend) lookupChild(parent int64, name string) (*inode, error) {
+	for _, ino := range m.inodes {
+		if ino.ParentID == parent && ino.Name == name && ino.ID != rootInodeID {
+			return ino, nil
+		}
+	}
+	return nil, ErrNotExist
+}
+
+// cloneInode copies ino so callers can't mutate backend state through a
+// returned pointer, matching sqliteStore's value semantics where every query
+// builds a fresh *inode from row data.
+func cloneInode(ino *inode) *inode {
+	cp := *ino
+	cp.Data = append([]byte(nil), ino.Data...)
+	return &cp
+}
+
+func (m *MemBackend) Resolve(ctx context.Context, p string) (*inode, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ino, err := m.resolveLocked(p)
+	if err != nil {
+		return nil, err
+	}
+	return cloneInode(ino), nil
+}
+
+func (m *MemBackend) resolveLocked(p string) (*inode, error) {
+	parts := splitPath(p)
+	cur := rootInodeID
+	var ino *inode
+	for i, name := range parts {
+		n, err := m.lookupChild(cur, name)
+		if err != nil {
+			return nil, err
+		}
+		if i != len(parts)-1 && !n.IsDir {
+			return nil, ErrNotDir
+		}
+		ino = n
+		cur = n.ID
+	}
+	if ino == nil {
+		return m.inodes[rootInodeID], nil
+	}
+	return ino, nil
+}
+
+// resolveParentLocked resolves the directory containing p and returns it
+// along with the final path component. Caller must hold m.mu.
+func (m *MemBackend) resolveParentLocked(p string) (*inode, string, error) {
+	parts := splitPath(p)
+	if len(parts) == 0 {
+		return nil, "", ErrExist // root has no parent to create against
+	}
+	dirPath := "/" + strings.Join(parts[:len(parts)-1], "/")
+	parent, err := m.resolveLocked(dirPath)
+	if err != nil {
+		return nil, "", err
+	}
+	if !parent.IsDir {
+		return nil, "", ErrNotDir
+	}
+	return parent, parts[len(parts)-1], nil
+}
+
+func (m *MemBackend) Children(ctx context.Context, dirID int64) ([]*inode, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []*inode
+	for _, ino := range m.inodes {
+		if ino.ParentID == dirID && ino.ID != rootInodeID {
+			out = append(out, cloneInode(ino))
+		}
+	}
+	return out, nil
+}
+
+func (m *MemBackend) CreateFile(ctx context.Context, p string, data []byte, mode fs.FileMode) (*inode, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	parent, name, err := m.resolveParentLocked(p)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	stored := append([]byte(nil), data...)
+	if existing, err := m.lookupChild(parent.ID, name); err == nil {
+		existing.Mode = mode
+		existing.Size = int64(len(data))
+		existing.Mtime = now
+		existing.Data = stored
+		return cloneInode(existing), nil
+	}
+	ino := &inode{ID: m.allocID(), ParentID: parent.ID, Name: name, Mode: mode, Size: int64(len(data)), Mtime: now, Data: stored}
+	m.inodes[ino.ID] = ino
+	return cloneInode(ino), nil
+}
+
+func (m *MemBackend) Mkdir(ctx context.Context, p string, mode fs.FileMode) (*inode, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	parent, name, err := m.resolveParentLocked(p)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.lookupChild(parent.ID, name); err == nil {
+		return nil, ErrExist
+	}
+	ino := &inode{ID: m.allocID(), ParentID: parent.ID, Name: name, Mode: mode | fs.ModeDir, IsDir: true, Mtime: time.Now()}
+	m.inodes[ino.ID] = ino
+	return cloneInode(ino), nil
+}
+
+func (m *MemBackend) Unlink(ctx context.Context, p string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ino, err := m.resolveLocked(p)
+	if err != nil {
+		return err
+	}
+	if ino.IsDir {
+		return ErrIsDir
+	}
+	delete(m.inodes, ino.ID)
+	return nil
+}
+
+func (m *MemBackend) Rmdir(ctx context.Context, p string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ino, err := m.resolveLocked(p)
+	if err != nil {
+		return err
+	}
+	if !ino.IsDir {
+		return ErrNotDir
+	}
+	for _, kid := range m.inodes {
+		if kid.ParentID == ino.ID {
+			return ErrNotEmpty
+		}
+	}
+	delete(m.inodes, ino.ID)
+	return nil
+}
+
+// Rename moves oldPath to newPath, overwriting newPath if it already exists
+// and the types are compatible: see Backend.Rename for the exact
+// ErrIsDir/ErrNotDir/ErrNotEmpty rules.
+func (m *MemBackend) Rename(ctx context.Context, oldPath, newPath string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ino, err := m.resolveLocked(oldPath)
+	if err != nil {
+		return err
+	}
+	newParent, newName, err := m.resolveParentLocked(newPath)
+	if err != nil {
+		return err
+	}
+	if existing, err := m.lookupChild(newParent.ID, newName); err == nil && existing.ID != ino.ID {
+		if existing.IsDir {
+			if !ino.IsDir {
+				return ErrIsDir
+			}
+			for _, kid := range m.inodes {
+				if kid.ParentID == existing.ID {
+					return ErrNotEmpty
+				}
+			}
+		} else if ino.IsDir {
+			return ErrNotDir
+		}
+		delete(m.inodes, existing.ID)
+	}
+	ino.ParentID = newParent.ID
+	ino.Name = newName
+	return nil
+}
+
+func (m *MemBackend) ReadRange(ctx context.Context, id int64, offset, length int64) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ino, ok := m.inodes[id]
+	if !ok {
+		return nil, ErrNotExist
+	}
+	if offset >= int64(len(ino.Data)) {
+		return nil, nil
+	}
+	end := offset + length
+	if end > int64(len(ino.Data)) {
+		end = int64(len(ino.Data))
+	}
+	out := make([]byte, end-offset)
+	copy(out, ino.Data[offset:end])
+	return out, nil
+}
+
+// allocID returns the next free inode ID. Caller must hold m.mu.
+func (m *MemBackend) allocID() int64 {
+	id := m.nextID
+	m.nextID++
+	return id
+}
@@ -0,0 +1,45 @@
+package agentfs
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMemBackend_SelectedByFakePathSentinel(t *testing.T) {
+	ctx := context.Background()
+	afs, err := Open(ctx, AgentFSOptions{Path: ":fake:"})
+	if err != nil {
+		t.Fatalf("Open with :fake: path failed: %v", err)
+	}
+	defer afs.Close()
+
+	if _, ok := afs.store.(*MemBackend); !ok {
+		t.Fatalf("store = %T, want *MemBackend", afs.store)
+	}
+	if err := afs.FS.WriteFile(ctx, "/hello.txt", []byte("hi"), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	data, err := afs.FS.ReadFile(ctx, "/hello.txt")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if string(data) != "hi" {
+		t.Errorf("ReadFile = %q, want %q", data, "hi")
+	}
+}
+
+func TestMemBackend_SelectedExplicitlyViaOptions(t *testing.T) {
+	ctx := context.Background()
+	afs, err := Open(ctx, AgentFSOptions{Backend: NewMemBackend()})
+	if err != nil {
+		t.Fatalf("Open with explicit Backend failed: %v", err)
+	}
+	defer afs.Close()
+
+	if err := afs.FS.MkdirAll(ctx, "/a/b", 0o755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	if _, err := afs.FS.Stat(ctx, "/a/b"); err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+}
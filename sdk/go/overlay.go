@@ -0,0 +1,318 @@
+package agentfs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path"
+	"sort"
+)
+
+// whiteoutMode marks an upper-layer entry as a tombstone: the path was
+// deleted from the overlay even though one or more lower layers still hold
+// it. fs.ModeIrregular is otherwise unused by AgentFS, so it doubles as the
+// marker bit without needing a new inode field.
+const whiteoutMode = fs.ModeIrregular
+
+func isWhiteout(info fs.FileInfo) bool {
+	return info.Mode()&fs.ModeIrregular != 0
+}
+
+// errWhiteout is returned internally by statUpper to mean "deliberately
+// deleted" as opposed to plain ErrNotExist's "never existed here" — callers
+// must stop at a whiteout rather than falling through to a lower layer.
+var errWhiteout = errors.New("agentfs: whited out")
+
+// OverlayFS is a copy-on-write filesystem that stacks a writable upper
+// AgentFS on top of one or more read-only lower layers. Reads fall through
+// to the first layer containing the path; the first write to a lower-only
+// path copies it (and its parent directories) into the upper layer, and
+// deletes of a lower-only path leave a whiteout tombstone in the upper
+// layer so the lower copy stops showing through.
+type OverlayFS struct {
+	upper *AgentFS
+	lower []fs.FS
+}
+
+// NewOverlayFS composes upper with the given lower layers, consulted in
+// order: the first layer containing a path wins.
+func NewOverlayFS(upper *AgentFS, lower ...fs.FS) *OverlayFS {
+	return &OverlayFS{upper: upper, lower: lower}
+}
+
+// AgentFSLayer adapts a as a read-only fs.FS so it can be used as a lower
+// layer in NewOverlayFS.
+func AgentFSLayer(a *AgentFS) fs.FS {
+	return newIoFSAdapter(a.FS)
+}
+
+func toLowerPath(p string) string {
+	p = path.Clean("/" + p)
+	if p == "/" {
+		return "."
+	}
+	return p[1:]
+}
+
+// statUpper returns the upper-layer info for path, or errWhiteout if the
+// upper layer holds a whiteout for it.
+func (o *OverlayFS) statUpper(ctx context.Context, p string) (fs.FileInfo, error) {
+	info, err := o.upper.FS.Stat(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	if isWhiteout(info) {
+		return nil, errWhiteout
+	}
+	return info, nil
+}
+
+// statLower returns the info and owning layer for the first lower layer
+// containing path.
+func (o *OverlayFS) statLower(p string) (fs.FileInfo, fs.FS, error) {
+	lp := toLowerPath(p)
+	for _, l := range o.lower {
+		if info, err := fs.Stat(l, lp); err == nil {
+			return info, l, nil
+		}
+	}
+	return nil, nil, ErrNotExist
+}
+
+// Stat returns file info for path, preferring the upper layer.
+func (o *OverlayFS) Stat(ctx context.Context, p string) (fs.FileInfo, error) {
+	info, err := o.statUpper(ctx, p)
+	switch {
+	case err == nil:
+		return info, nil
+	case errors.Is(err, errWhiteout):
+		return nil, ErrNotExist
+	case !IsNotExist(err):
+		return nil, err
+	}
+	info, _, err = o.statLower(p)
+	if err != nil {
+		return nil, ErrNotExist
+	}
+	return info, nil
+}
+
+// ReadFile returns the contents of path, preferring the upper layer.
+func (o *OverlayFS) ReadFile(ctx context.Context, p string) ([]byte, error) {
+	_, err := o.statUpper(ctx, p)
+	switch {
+	case err == nil:
+		return o.upper.FS.ReadFile(ctx, p)
+	case errors.Is(err, errWhiteout):
+		return nil, ErrNotExist
+	case !IsNotExist(err):
+		return nil, err
+	}
+	_, layer, err := o.statLower(p)
+	if err != nil {
+		return nil, ErrNotExist
+	}
+	return fs.ReadFile(layer, toLowerPath(p))
+}
+
+// copyUpParents ensures path's parent directories exist in the upper layer, using
+// the lower layer's directory mode where available. It does not copy file
+// contents; callers that need the prior contents (a partial write) should
+// read them via ReadFile before calling WriteFile.
+func (o *OverlayFS) copyUpParents(ctx context.Context, p string) error {
+	dir := path.Dir(path.Clean("/" + p))
+	if dir == "/" {
+		return nil
+	}
+	mode := fs.FileMode(0o755)
+	if info, err := o.Stat(ctx, dir); err == nil {
+		mode = info.Mode().Perm()
+	}
+	return o.upper.FS.MkdirAll(ctx, dir, mode)
+}
+
+// WriteFile creates or overwrites the file at path in the upper layer,
+// materializing parent directories as needed. Writing through a whiteout
+// simply replaces it, since the new data carries a regular mode.
+func (o *OverlayFS) WriteFile(ctx context.Context, p string, data []byte, mode fs.FileMode) error {
+	if err := o.copyUpParents(ctx, p); err != nil {
+		return err
+	}
+	return o.upper.FS.WriteFile(ctx, p, data, mode)
+}
+
+// MkdirAll creates dirPath (and parents) in the upper layer.
+func (o *OverlayFS) MkdirAll(ctx context.Context, dirPath string, mode fs.FileMode) error {
+	return o.upper.FS.MkdirAll(ctx, dirPath, mode)
+}
+
+// Unlink removes path from the overlay's view. If the upper layer holds a
+// real copy it is deleted outright; if any lower layer still holds the
+// path, a whiteout tombstone is written (or left) in the upper layer so
+// subsequent reads see ENOENT instead of falling through.
+func (o *OverlayFS) Unlink(ctx context.Context, p string) error {
+	upperInfo, upperErr := o.statUpper(ctx, p)
+	if errors.Is(upperErr, errWhiteout) {
+		return ErrNotExist
+	}
+	hasUpper := upperErr == nil
+	lowerInfo, _, lowerErr := o.statLower(p)
+	hasLower := lowerErr == nil
+
+	if !hasUpper && !hasLower {
+		return ErrNotExist
+	}
+	if hasLower && lowerInfo.IsDir() {
+		return ErrIsDir
+	}
+	if hasUpper {
+		if upperInfo.IsDir() {
+			return ErrIsDir
+		}
+		if err := o.upper.FS.Unlink(ctx, p); err != nil {
+			return err
+		}
+	}
+	if hasLower {
+		if err := o.copyUpParents(ctx, p); err != nil {
+			return err
+		}
+		if err := o.upper.FS.WriteFile(ctx, p, nil, whiteoutMode); err != nil {
+			return err
+		}
+	}
+	o.upper.FS.invalidate(p)
+	return nil
+}
+
+// Rmdir removes the directory at path, which must be empty across all
+// layers once whiteouts are accounted for.
+func (o *OverlayFS) Rmdir(ctx context.Context, p string) error {
+	entries, err := o.ReadDir(ctx, p)
+	if err != nil {
+		return err
+	}
+	if len(entries) > 0 {
+		return ErrNotEmpty
+	}
+	upperInfo, upperErr := o.statUpper(ctx, p)
+	if errors.Is(upperErr, errWhiteout) {
+		return ErrNotExist
+	}
+	hasUpper := upperErr == nil
+	_, _, lowerErr := o.statLower(p)
+	hasLower := lowerErr == nil
+
+	if !hasUpper && !hasLower {
+		return ErrNotExist
+	}
+	if hasUpper {
+		if !upperInfo.IsDir() {
+			return ErrNotDir
+		}
+		if err := o.upper.FS.Rmdir(ctx, p); err != nil {
+			return err
+		}
+	}
+	if hasLower {
+		if err := o.copyUpParents(ctx, p); err != nil {
+			return err
+		}
+		if err := o.upper.FS.WriteFile(ctx, p, nil, whiteoutMode); err != nil {
+			return err
+		}
+	}
+	o.upper.FS.invalidate(p)
+	return nil
+}
+
+// Rename moves oldPath to newPath. A lower-only source is copied up first;
+// if it also exists in a lower layer under oldPath, a whiteout is left
+// behind so oldPath stops resolving there.
+func (o *OverlayFS) Rename(ctx context.Context, oldPath, newPath string) error {
+	_, upperErr := o.statUpper(ctx, oldPath)
+	switch {
+	case errors.Is(upperErr, errWhiteout):
+		return ErrNotExist
+	case IsNotExist(upperErr):
+		data, rerr := o.ReadFile(ctx, oldPath)
+		if rerr != nil {
+			return rerr
+		}
+		info, serr := o.Stat(ctx, oldPath)
+		if serr != nil {
+			return serr
+		}
+		if err := o.copyUpParents(ctx, oldPath); err != nil {
+			return err
+		}
+		if err := o.upper.FS.WriteFile(ctx, oldPath, data, info.Mode()); err != nil {
+			return err
+		}
+	case upperErr != nil:
+		return upperErr
+	}
+	if err := o.copyUpParents(ctx, newPath); err != nil {
+		return err
+	}
+	if err := o.upper.FS.Rename(ctx, oldPath, newPath); err != nil {
+		return err
+	}
+	if _, _, err := o.statLower(oldPath); err == nil {
+		if err := o.upper.FS.WriteFile(ctx, oldPath, nil, whiteoutMode); err != nil {
+			return err
+		}
+	}
+	o.upper.FS.invalidate(oldPath)
+	o.upper.FS.invalidate(newPath)
+	return nil
+}
+
+// ReadDir returns the merged, deduplicated, whiteout-aware directory
+// listing for path across the upper layer and all lower layers.
+func (o *OverlayFS) ReadDir(ctx context.Context, p string) ([]fs.DirEntry, error) {
+	seen := make(map[string]fs.DirEntry)
+	whited := make(map[string]bool)
+	lp := toLowerPath(p)
+	foundAny := false
+
+	if entries, err := newIoFSAdapter(o.upper.FS).ReadDir(lp); err == nil {
+		foundAny = true
+		for _, e := range entries {
+			info, ierr := e.Info()
+			if ierr == nil && isWhiteout(info) {
+				whited[e.Name()] = true
+				continue
+			}
+			seen[e.Name()] = e
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	for _, l := range o.lower {
+		entries, err := fs.ReadDir(l, lp)
+		if err != nil {
+			continue
+		}
+		foundAny = true
+		for _, e := range entries {
+			if whited[e.Name()] {
+				continue
+			}
+			if _, ok := seen[e.Name()]; !ok {
+				seen[e.Name()] = e
+			}
+		}
+	}
+	if !foundAny {
+		return nil, ErrNotExist
+	}
+
+	out := make([]fs.DirEntry, 0, len(seen))
+	for _, e := range seen {
+		out = append(out, e)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name() < out[j].Name() })
+	return out, nil
+}
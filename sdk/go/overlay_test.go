@@ -0,0 +1,177 @@
+package agentfs
+
+import (
+	"context"
+	"testing"
+)
+
+func setupOverlay(t *testing.T) (*OverlayFS, *AgentFS) {
+	t.Helper()
+	ctx := context.Background()
+	lowerFS, err := Open(ctx, AgentFSOptions{Path: ":memory:"})
+	if err != nil {
+		t.Fatalf("Failed to open lower AgentFS: %v", err)
+	}
+	t.Cleanup(func() { lowerFS.Close() })
+	if err := lowerFS.FS.WriteFile(ctx, "/base.txt", []byte("base"), 0o644); err != nil {
+		t.Fatalf("seed lower failed: %v", err)
+	}
+	if err := lowerFS.FS.MkdirAll(ctx, "/dir", 0o755); err != nil {
+		t.Fatalf("seed lower dir failed: %v", err)
+	}
+	if err := lowerFS.FS.WriteFile(ctx, "/dir/nested.txt", []byte("nested"), 0o644); err != nil {
+		t.Fatalf("seed lower nested failed: %v", err)
+	}
+
+	upper, err := Open(ctx, AgentFSOptions{Path: ":memory:"})
+	if err != nil {
+		t.Fatalf("Failed to open upper AgentFS: %v", err)
+	}
+	t.Cleanup(func() { upper.Close() })
+
+	return NewOverlayFS(upper, AgentFSLayer(lowerFS)), upper
+}
+
+func TestOverlayFS_ReadsFallThroughToLower(t *testing.T) {
+	ov, _ := setupOverlay(t)
+	ctx := context.Background()
+
+	data, err := ov.ReadFile(ctx, "/base.txt")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if string(data) != "base" {
+		t.Errorf("ReadFile = %q, want %q", data, "base")
+	}
+}
+
+func TestOverlayFS_WriteCopiesUp(t *testing.T) {
+	ov, upper := setupOverlay(t)
+	ctx := context.Background()
+
+	if err := ov.WriteFile(ctx, "/base.txt", []byte("modified"), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	data, err := upper.FS.ReadFile(ctx, "/base.txt")
+	if err != nil {
+		t.Fatalf("upper ReadFile failed: %v", err)
+	}
+	if string(data) != "modified" {
+		t.Errorf("upper copy = %q, want %q", data, "modified")
+	}
+
+	data, err = ov.ReadFile(ctx, "/base.txt")
+	if err != nil {
+		t.Fatalf("overlay ReadFile failed: %v", err)
+	}
+	if string(data) != "modified" {
+		t.Errorf("overlay ReadFile = %q, want %q", data, "modified")
+	}
+}
+
+func TestOverlayFS_UnlinkWhiteoutHidesLower(t *testing.T) {
+	ov, _ := setupOverlay(t)
+	ctx := context.Background()
+
+	if err := ov.Unlink(ctx, "/base.txt"); err != nil {
+		t.Fatalf("Unlink failed: %v", err)
+	}
+
+	if _, err := ov.Stat(ctx, "/base.txt"); !IsNotExist(err) {
+		t.Errorf("Stat after Unlink = %v, want ErrNotExist", err)
+	}
+}
+
+func TestOverlayFS_ReadDirMergesLayers(t *testing.T) {
+	ov, upper := setupOverlay(t)
+	ctx := context.Background()
+
+	if err := upper.FS.WriteFile(ctx, "/only-upper.txt", []byte("x"), 0o644); err != nil {
+		t.Fatalf("upper WriteFile failed: %v", err)
+	}
+
+	entries, err := ov.ReadDir(ctx, "/")
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	names := map[string]bool{}
+	for _, e := range entries {
+		names[e.Name()] = true
+	}
+	if !names["base.txt"] || !names["dir"] || !names["only-upper.txt"] {
+		t.Errorf("ReadDir entries = %v, missing expected names", names)
+	}
+}
+
+func TestOverlayFS_UnlinkThenReadDirHidesWhiteout(t *testing.T) {
+	ov, _ := setupOverlay(t)
+	ctx := context.Background()
+
+	if err := ov.Unlink(ctx, "/base.txt"); err != nil {
+		t.Fatalf("Unlink failed: %v", err)
+	}
+
+	entries, err := ov.ReadDir(ctx, "/")
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	for _, e := range entries {
+		if e.Name() == "base.txt" {
+			t.Error("ReadDir should not list whited-out base.txt")
+		}
+	}
+}
+
+func TestOverlayFS_RenameOfWhitedOutPathFails(t *testing.T) {
+	ov, _ := setupOverlay(t)
+	ctx := context.Background()
+
+	if err := ov.Unlink(ctx, "/base.txt"); err != nil {
+		t.Fatalf("Unlink failed: %v", err)
+	}
+
+	if err := ov.Rename(ctx, "/base.txt", "/moved.txt"); !IsNotExist(err) {
+		t.Errorf("Rename of whited-out path = %v, want ErrNotExist", err)
+	}
+
+	if _, err := ov.Stat(ctx, "/moved.txt"); !IsNotExist(err) {
+		t.Errorf("Stat(/moved.txt) = %v, want ErrNotExist (rename must not have planted a whiteout there)", err)
+	}
+}
+
+func TestOverlayFS_RenameCopiesLowerOnlyFileUp(t *testing.T) {
+	ov, upper := setupOverlay(t)
+	ctx := context.Background()
+
+	if err := ov.Rename(ctx, "/base.txt", "/renamed.txt"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+
+	data, err := ov.ReadFile(ctx, "/renamed.txt")
+	if err != nil {
+		t.Fatalf("ReadFile(/renamed.txt) failed: %v", err)
+	}
+	if string(data) != "base" {
+		t.Errorf("ReadFile(/renamed.txt) = %q, want %q", data, "base")
+	}
+	if _, err := ov.Stat(ctx, "/base.txt"); !IsNotExist(err) {
+		t.Errorf("Stat(/base.txt) after rename = %v, want ErrNotExist", err)
+	}
+	if _, err := upper.FS.Stat(ctx, "/base.txt"); err != nil {
+		t.Errorf("upper should hold a whiteout for /base.txt, Stat failed: %v", err)
+	}
+}
+
+func TestOverlayFS_UnlinkLowerOnlyDirReturnsErrIsDir(t *testing.T) {
+	ov, _ := setupOverlay(t)
+	ctx := context.Background()
+
+	if err := ov.Unlink(ctx, "/dir"); err != ErrIsDir {
+		t.Errorf("Unlink(/dir) = %v, want ErrIsDir", err)
+	}
+
+	if _, err := ov.Stat(ctx, "/dir/nested.txt"); err != nil {
+		t.Errorf("Stat(/dir/nested.txt) after failed Unlink = %v, want nil (directory must not be whited out)", err)
+	}
+}
@@ -0,0 +1,319 @@
+package agentfs
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+const rootInodeID int64 = 1
+
+// sqliteStore is the default inode/dirent storage engine. It keeps every
+// filesystem entry as a row addressed by (parent_id, name), so path
+// resolution is a sequence of indexed lookups rather than a single query.
+type sqliteStore struct {
+	db *sql.DB
+}
+
+func openSQLiteStore(ctx context.Context, dsn string) (*sqliteStore, error) {
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("agentfs: open sqlite store: %w", err)
+	}
+	s := &sqliteStore{db: db}
+	if err := s.init(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *sqliteStore) init(ctx context.Context) error {
+	const schema = `
+CREATE TABLE IF NOT EXISTS inodes (
+	id        INTEGER PRIMARY KEY AUTOINCREMENT,
+	parent_id INTEGER NOT NULL,
+	name      TEXT NOT NULL,
+	mode      INTEGER NOT NULL,
+	size      INTEGER NOT NULL DEFAULT 0,
+	mtime     INTEGER NOT NULL,
+	is_dir    INTEGER NOT NULL,
+	data      BLOB,
+	UNIQUE(parent_id, name)
+);
+CREATE INDEX IF NOT EXISTS idx_inodes_parent ON inodes(parent_id);
+`
+	if _, err := s.db.ExecContext(ctx, schema); err != nil {
+		return fmt.Errorf("agentfs: init schema: %w", err)
+	}
+	var count int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM inodes WHERE id = ?`, rootInodeID).Scan(&count); err != nil {
+		return fmt.Errorf("agentfs: check root inode: %w", err)
+	}
+	if count == 0 {
+		_, err := s.db.ExecContext(ctx, `INSERT INTO inodes (id, parent_id, name, mode, size, mtime, is_dir) VALUES (?, ?, '/', ?, 0, ?, 1)`,
+			rootInodeID, rootInodeID, 0o755, time.Now().Unix())
+		if err != nil {
+			return fmt.Errorf("agentfs: create root inode: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *sqliteStore) Close() error {
+	return s.db.Close()
+}
+
+// splitPath returns the cleaned, non-empty path components of p.
+func splitPath(p string) []string {
+	p = path.Clean("/" + p)
+	if p == "/" {
+		return nil
+	}
+	return strings.Split(strings.TrimPrefix(p, "/"), "/")
+}
+
+func scanInode(row interface{ Scan(...any) error }) (*inode, error) {
+	ino := &inode{}
+	var mode uint32
+	var mtimeUnix int64
+	var isDir int
+	if err := row.Scan(&ino.ID, &ino.ParentID, &ino.Name, &mode, &ino.Size, &mtimeUnix, &isDir, &ino.Data); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotExist
+		}
+		return nil, err
+	}
+	ino.Mode = fs.FileMode(mode)
+	if isDir != 0 {
+		ino.Mode |= fs.ModeDir
+	}
+	ino.Mtime = time.Unix(mtimeUnix, 0)
+	ino.IsDir = isDir != 0
+	return ino, nil
+}
+
+const inodeColumns = `id, parent_id, name, mode, size, mtime, is_dir, data`
+
+// lookupChild returns the child of parent named name, or ErrNotExist.
+func (s *sqliteStore) lookupChild(ctx context.Context, parent int64, name string) (*inode, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+inodeColumns+` FROM inodes WHERE parent_id = ? AND name = ?`, parent, name)
+	return scanInode(row)
+}
+
+// Resolve walks components from the root and returns the final inode.
+func (s *sqliteStore) Resolve(ctx context.Context, p string) (*inode, error) {
+	parts := splitPath(p)
+	cur := rootInodeID
+	var ino *inode
+	for i, name := range parts {
+		n, err := s.lookupChild(ctx, cur, name)
+		if err != nil {
+			return nil, err
+		}
+		if i != len(parts)-1 && !n.IsDir {
+			return nil, ErrNotDir
+		}
+		ino = n
+		cur = n.ID
+	}
+	if ino == nil {
+		// path was "/"
+		row := s.db.QueryRowContext(ctx, `SELECT `+inodeColumns+` FROM inodes WHERE id = ?`, rootInodeID)
+		return scanInode(row)
+	}
+	return ino, nil
+}
+
+func (s *sqliteStore) Children(ctx context.Context, dirID int64) ([]*inode, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+inodeColumns+` FROM inodes WHERE parent_id = ? AND id != ?`, dirID, rootInodeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*inode
+	for rows.Next() {
+		ino, err := scanInode(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, ino)
+	}
+	return out, rows.Err()
+}
+
+// resolveParent resolves the directory containing p and returns it along
+// with the final path component.
+func (s *sqliteStore) resolveParent(ctx context.Context, p string) (*inode, string, error) {
+	parts := splitPath(p)
+	if len(parts) == 0 {
+		return nil, "", ErrExist // root has no parent to create against
+	}
+	dirPath := "/" + strings.Join(parts[:len(parts)-1], "/")
+	parent, err := s.Resolve(ctx, dirPath)
+	if err != nil {
+		return nil, "", err
+	}
+	if !parent.IsDir {
+		return nil, "", ErrNotDir
+	}
+	return parent, parts[len(parts)-1], nil
+}
+
+func (s *sqliteStore) CreateFile(ctx context.Context, p string, data []byte, mode fs.FileMode) (*inode, error) {
+	parent, name, err := s.resolveParent(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO inodes (parent_id, name, mode, size, mtime, is_dir, data) VALUES (?, ?, ?, ?, ?, 0, ?)
+		ON CONFLICT(parent_id, name) DO UPDATE SET mode = excluded.mode, size = excluded.size, mtime = excluded.mtime, data = excluded.data`,
+		parent.ID, name, uint32(mode), len(data), now, data)
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	if id == 0 {
+		existing, err := s.lookupChild(ctx, parent.ID, name)
+		if err != nil {
+			return nil, err
+		}
+		id = existing.ID
+	}
+	return &inode{ID: id, ParentID: parent.ID, Name: name, Mode: mode, Size: int64(len(data)), Mtime: time.Unix(now, 0), Data: data}, nil
+}
+
+func (s *sqliteStore) Mkdir(ctx context.Context, p string, mode fs.FileMode) (*inode, error) {
+	parent, name, err := s.resolveParent(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	res, err := s.db.ExecContext(ctx, `INSERT INTO inodes (parent_id, name, mode, size, mtime, is_dir) VALUES (?, ?, ?, 0, ?, 1)`,
+		parent.ID, name, uint32(mode), now)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrExist
+		}
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return &inode{ID: id, ParentID: parent.ID, Name: name, Mode: mode | fs.ModeDir, IsDir: true, Mtime: time.Unix(now, 0)}, nil
+}
+
+func (s *sqliteStore) Unlink(ctx context.Context, p string) error {
+	ino, err := s.Resolve(ctx, p)
+	if err != nil {
+		return err
+	}
+	if ino.IsDir {
+		return ErrIsDir
+	}
+	_, err = s.db.ExecContext(ctx, `DELETE FROM inodes WHERE id = ?`, ino.ID)
+	return err
+}
+
+func (s *sqliteStore) Rmdir(ctx context.Context, p string) error {
+	ino, err := s.Resolve(ctx, p)
+	if err != nil {
+		return err
+	}
+	if !ino.IsDir {
+		return ErrNotDir
+	}
+	kids, err := s.Children(ctx, ino.ID)
+	if err != nil {
+		return err
+	}
+	if len(kids) > 0 {
+		return ErrNotEmpty
+	}
+	_, err = s.db.ExecContext(ctx, `DELETE FROM inodes WHERE id = ?`, ino.ID)
+	return err
+}
+
+// Rename moves oldPath to newPath, overwriting newPath if it already exists
+// and the types are compatible (POSIX rename semantics): a file can replace
+// a file or an empty directory can replace an empty directory, but
+// ErrIsDir/ErrNotDir/ErrNotEmpty reject a type mismatch or a non-empty
+// directory destination rather than silently destroying it. The existing
+// destination, if any, is deleted in the same transaction as the reparent so
+// a concurrent reader never observes both the old and new rows for newPath
+// at once.
+func (s *sqliteStore) Rename(ctx context.Context, oldPath, newPath string) error {
+	ino, err := s.Resolve(ctx, oldPath)
+	if err != nil {
+		return err
+	}
+	newParent, newName, err := s.resolveParent(ctx, newPath)
+	if err != nil {
+		return err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	row := tx.QueryRowContext(ctx, `SELECT `+inodeColumns+` FROM inodes WHERE parent_id = ? AND name = ?`, newParent.ID, newName)
+	existing, err := scanInode(row)
+	switch {
+	case err == nil && existing.ID != ino.ID:
+		if existing.IsDir {
+			if !ino.IsDir {
+				return ErrIsDir
+			}
+			var count int
+			if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM inodes WHERE parent_id = ?`, existing.ID).Scan(&count); err != nil {
+				return err
+			}
+			if count > 0 {
+				return ErrNotEmpty
+			}
+		} else if ino.IsDir {
+			return ErrNotDir
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM inodes WHERE id = ?`, existing.ID); err != nil {
+			return err
+		}
+	case err != nil && !IsNotExist(err):
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE inodes SET parent_id = ?, name = ? WHERE id = ?`, newParent.ID, newName, ino.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ReadRange returns up to length bytes starting at offset from inode id's
+// data, without loading the full blob — used by the chunk cache so a miss
+// on one chunk doesn't pull the entire file into memory.
+func (s *sqliteStore) ReadRange(ctx context.Context, id int64, offset, length int64) ([]byte, error) {
+	var data []byte
+	// sqlite's substr is 1-indexed.
+	row := s.db.QueryRowContext(ctx, `SELECT substr(data, ?, ?) FROM inodes WHERE id = ?`, offset+1, length, id)
+	if err := row.Scan(&data); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotExist
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+func isUniqueViolation(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint")
+}
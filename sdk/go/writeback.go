@@ -0,0 +1,167 @@
+package agentfs
+
+import (
+	"context"
+	"io/fs"
+	"time"
+)
+
+// dirtyRecord is a buffered write awaiting flush to the store. It is kept
+// alongside a full snapshot of the inode's pending contents so Stat/Read
+// can serve write-your-reads consistency straight out of the path cache.
+type dirtyRecord struct {
+	path  string
+	ino   *inode
+	timer *time.Timer
+}
+
+func (f *FS) writebackEnabled() bool {
+	return f.cache != nil && f.cache.opts.Writeback > 0
+}
+
+// dirtyByPath returns the buffered inode for path, if a write is pending.
+func (f *FS) dirtyByPath(path string) (*inode, bool) {
+	c := f.cache
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if rec, ok := c.dirty[path]; ok {
+		return rec.ino, true
+	}
+	return nil, false
+}
+
+// bufferWrite stages data for path in the dirty buffer and (re)arms its
+// coalescing timer. Back-to-back writes to the same inode within the
+// writeback window collapse into a single flush, and the path cache is
+// updated so Stat/Read observe the pending contents immediately.
+func (f *FS) bufferWrite(ctx context.Context, path string, data []byte, mode fs.FileMode) error {
+	c := f.cache
+	ino := &inode{Name: path, Mode: mode, Size: int64(len(data)), Mtime: time.Now(), Data: data}
+
+	c.mu.Lock()
+	if existing, ok := c.entries[path]; ok {
+		ino.ID = existing.ino.ID
+		ino.ParentID = existing.ino.ParentID
+	}
+	if rec, ok := c.dirty[path]; ok {
+		delta := int64(len(data)) - int64(len(rec.ino.Data))
+		if c.opts.WritebackMaxDirty > 0 && delta > 0 && c.dirtyBytes+delta > int64(c.opts.WritebackMaxDirty) {
+			c.mu.Unlock()
+			return ErrDirtyBufferFull
+		}
+		c.dirtyBytes += delta
+		rec.ino = ino
+		rec.timer.Reset(c.opts.Writeback)
+	} else {
+		newBytes := int64(len(data))
+		if c.opts.WritebackMaxDirty > 0 && c.dirtyBytes+newBytes > int64(c.opts.WritebackMaxDirty) {
+			c.mu.Unlock()
+			return ErrDirtyBufferFull
+		}
+		c.dirtyBytes += newBytes
+		rec := &dirtyRecord{path: path, ino: ino}
+		rec.timer = time.AfterFunc(c.opts.Writeback, func() { f.flushInode(context.Background(), path) })
+		c.dirty[path] = rec
+	}
+	c.mu.Unlock()
+
+	c.put(path, ino)
+	return nil
+}
+
+// flushInode persists the dirty record for path, if one is still pending (a
+// force-flush or dropDirty may have already removed it). It does not check
+// which timer scheduled it: whatever record is pending for path is flushed.
+func (f *FS) flushInode(ctx context.Context, path string) {
+	c := f.cache
+	c.mu.Lock()
+	rec, ok := c.dirty[path]
+	if !ok {
+		c.mu.Unlock()
+		return
+	}
+	delete(c.dirty, path)
+	c.dirtyBytes -= int64(len(rec.ino.Data))
+	c.mu.Unlock()
+
+	start := time.Now()
+	persisted, err := f.store.CreateFile(ctx, rec.path, rec.ino.Data, rec.ino.Mode)
+	elapsed := time.Since(start)
+
+	c.mu.Lock()
+	c.flushCount++
+	c.flushLatency += elapsed
+	c.mu.Unlock()
+
+	if err == nil {
+		c.put(rec.path, persisted)
+		f.bumpParentGen(ctx, rec.path)
+	}
+}
+
+// forceFlushPath synchronously flushes path if it has a pending write,
+// cancelling its coalescing timer first so the background flusher doesn't
+// also fire.
+func (f *FS) forceFlushPath(ctx context.Context, path string) error {
+	c := f.cache
+	c.mu.Lock()
+	rec, ok := c.dirty[path]
+	if !ok {
+		c.mu.Unlock()
+		return nil
+	}
+	rec.timer.Stop()
+	delete(c.dirty, path)
+	c.dirtyBytes -= int64(len(rec.ino.Data))
+	c.mu.Unlock()
+
+	start := time.Now()
+	persisted, err := f.store.CreateFile(ctx, rec.path, rec.ino.Data, rec.ino.Mode)
+	elapsed := time.Since(start)
+	c.mu.Lock()
+	c.flushCount++
+	c.flushLatency += elapsed
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	c.put(rec.path, persisted)
+	f.bumpParentGen(ctx, rec.path)
+	return nil
+}
+
+// dropDirty discards any pending write for path without persisting it,
+// reporting whether one was present. Used by Unlink so a delete of a
+// not-yet-flushed file doesn't resurrect it via the background flusher.
+func (f *FS) dropDirty(path string) bool {
+	c := f.cache
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	rec, ok := c.dirty[path]
+	if !ok {
+		return false
+	}
+	rec.timer.Stop()
+	delete(c.dirty, path)
+	c.dirtyBytes -= int64(len(rec.ino.Data))
+	return true
+}
+
+// flushAll synchronously flushes every pending dirty record, used by Sync
+// and Close so no buffered data is lost.
+func (f *FS) flushAll(ctx context.Context) error {
+	c := f.cache
+	c.mu.Lock()
+	paths := make([]string, 0, len(c.dirty))
+	for p := range c.dirty {
+		paths = append(paths, p)
+	}
+	c.mu.Unlock()
+
+	for _, p := range paths {
+		if err := f.forceFlushPath(ctx, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package agentfs
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func setupTestDBWithWriteback(t *testing.T, delay time.Duration, maxDirty int) *AgentFS {
+	t.Helper()
+	ctx := context.Background()
+	afs, err := Open(ctx, AgentFSOptions{
+		Path: ":memory:",
+		Cache: CacheOptions{
+			Enabled:           true,
+			MaxEntries:        1000,
+			Writeback:         delay,
+			WritebackMaxDirty: maxDirty,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to open AgentFS with writeback: %v", err)
+	}
+	return afs
+}
+
+func TestWriteback_ReadYourWrites(t *testing.T) {
+	afs := setupTestDBWithWriteback(t, 50*time.Millisecond, 0)
+	defer afs.Close()
+	ctx := context.Background()
+
+	if err := afs.FS.WriteFile(ctx, "/buffered.txt", []byte("hello"), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	stats := afs.FS.CacheStats()
+	if stats.DirtyEntries == 0 {
+		t.Error("Expected a dirty entry immediately after a buffered write")
+	}
+
+	data, err := afs.FS.ReadFile(ctx, "/buffered.txt")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if string(data) != "hello" {
+		t.Errorf("ReadFile = %q, want %q (write-your-reads)", data, "hello")
+	}
+}
+
+func TestWriteback_CoalescesRapidWrites(t *testing.T) {
+	afs := setupTestDBWithWriteback(t, 100*time.Millisecond, 0)
+	defer afs.Close()
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		if err := afs.FS.WriteFile(ctx, "/hot.txt", []byte{byte('a' + i)}, 0o644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+
+	time.Sleep(200 * time.Millisecond)
+
+	stats := afs.FS.CacheStats()
+	if stats.FlushCount != 1 {
+		t.Errorf("FlushCount = %d, want 1 (writes should coalesce)", stats.FlushCount)
+	}
+	if stats.DirtyEntries != 0 {
+		t.Errorf("DirtyEntries = %d, want 0 after flush", stats.DirtyEntries)
+	}
+
+	data, err := afs.FS.ReadFile(ctx, "/hot.txt")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if string(data) != "e" {
+		t.Errorf("ReadFile = %q, want %q (last write wins)", data, "e")
+	}
+}
+
+func TestWriteback_SyncForcesFlush(t *testing.T) {
+	afs := setupTestDBWithWriteback(t, time.Hour, 0)
+	defer afs.Close()
+	ctx := context.Background()
+
+	if err := afs.FS.WriteFile(ctx, "/synced.txt", []byte("x"), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := afs.FS.Sync(ctx); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	stats := afs.FS.CacheStats()
+	if stats.DirtyEntries != 0 {
+		t.Error("Expected no dirty entries after Sync")
+	}
+	if stats.FlushCount == 0 {
+		t.Error("Expected Sync to trigger a flush")
+	}
+}
+
+func TestWriteback_BackpressureOnFullBuffer(t *testing.T) {
+	afs := setupTestDBWithWriteback(t, time.Hour, 4)
+	defer afs.Close()
+	ctx := context.Background()
+
+	if err := afs.FS.WriteFile(ctx, "/small.txt", []byte("ab"), 0o644); err != nil {
+		t.Fatalf("first WriteFile failed: %v", err)
+	}
+	err := afs.FS.WriteFile(ctx, "/overflow.txt", []byte("abcdef"), 0o644)
+	if err != ErrDirtyBufferFull {
+		t.Errorf("WriteFile over budget = %v, want ErrDirtyBufferFull", err)
+	}
+}
+
+func TestWriteback_RenameFlushesDirtyPath(t *testing.T) {
+	afs := setupTestDBWithWriteback(t, time.Hour, 0)
+	defer afs.Close()
+	ctx := context.Background()
+
+	if err := afs.FS.WriteFile(ctx, "/before.txt", []byte("data"), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := afs.FS.Rename(ctx, "/before.txt", "/after.txt"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+
+	data, err := afs.FS.ReadFile(ctx, "/after.txt")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if string(data) != "data" {
+		t.Errorf("ReadFile = %q, want %q", data, "data")
+	}
+}
+
+func TestWriteback_RenameDropsStalePendingWriteAtDestination(t *testing.T) {
+	afs := setupTestDBWithWriteback(t, time.Hour, 0)
+	defer afs.Close()
+	ctx := context.Background()
+
+	if err := afs.FS.WriteFile(ctx, "/src", []byte("fromrename"), 0o644); err != nil {
+		t.Fatalf("WriteFile(/src) failed: %v", err)
+	}
+	if err := afs.FS.WriteFile(ctx, "/dst", []byte("stale-pending"), 0o644); err != nil {
+		t.Fatalf("WriteFile(/dst) failed: %v", err)
+	}
+	if err := afs.FS.Rename(ctx, "/src", "/dst"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+
+	// /dst's original dirty record must have been dropped by Rename, so
+	// firing its stale flusher now must not resurrect "stale-pending".
+	afs.FS.flushInode(ctx, "/dst")
+
+	data, err := afs.FS.ReadFile(ctx, "/dst")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if string(data) != "fromrename" {
+		t.Errorf("ReadFile(/dst) = %q, want %q (stale pending write clobbered the rename)", data, "fromrename")
+	}
+}
+
+func TestWriteback_MaxDirtyEnforcedOnCoalescingWrite(t *testing.T) {
+	afs := setupTestDBWithWriteback(t, time.Hour, 4)
+	defer afs.Close()
+	ctx := context.Background()
+
+	if err := afs.FS.WriteFile(ctx, "/a", []byte("ab"), 0o644); err != nil {
+		t.Fatalf("first WriteFile failed: %v", err)
+	}
+	err := afs.FS.WriteFile(ctx, "/a", []byte("abcdefghijklmnopqrstuvwxyz"), 0o644)
+	if err != ErrDirtyBufferFull {
+		t.Errorf("rewrite over budget = %v, want ErrDirtyBufferFull", err)
+	}
+
+	stats := afs.FS.CacheStats()
+	if stats.DirtyBytes > 4 {
+		t.Errorf("DirtyBytes = %d, want <= 4 (MaxDirty)", stats.DirtyBytes)
+	}
+}